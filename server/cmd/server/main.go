@@ -51,6 +51,37 @@ func checkEnv() {
 		log.Println("[WARN] DEV_USER: production では無効だが設定されている")
 	}
 
+	if os.Getenv("ADMIN_USERS") == "" {
+		log.Println("[WARN] ADMIN_USERS: 未設定（管理者向けの機能が使えない）")
+	} else {
+		log.Println("[OK]   ADMIN_USERS")
+	}
+
+	if config.TagProposalMode() {
+		log.Printf("[OK]   TAG_PROPOSAL_MODE=true（%d人が使うか管理者が承認するまで新しいタグは提案中）", config.TagApprovalThreshold())
+	}
+
+	if words, err := config.NGWords(); err != nil {
+		log.Fatalf("[FAIL] NG_WORDS_FILE: %v", err)
+	} else {
+		log.Printf("[OK]   コンテンツポリシー（説明文は%d文字・リンク%d個まで、NGワード%d語、投稿は1分間に%d件まで）",
+			config.DescriptionMaxLength(), config.DescriptionMaxLinks(), len(words), config.SubmissionRateLimit())
+	}
+
+	switch config.DescriptionGenerator() {
+	case "":
+		log.Println("[WARN] DESCRIPTION_GENERATOR: 未設定（説明文を自動で生成しない）")
+	case "openai":
+		if config.DescriptionGeneratorAPIKey() == "" {
+			log.Println("[WARN] DESCRIPTION_GENERATOR_API_KEY: 未設定")
+		}
+		log.Printf("[OK]   DESCRIPTION_GENERATOR=openai（%s, %s）", config.DescriptionGeneratorBaseURL(), config.DescriptionGeneratorModel())
+	case "fake":
+		log.Println("[WARN] DESCRIPTION_GENERATOR=fake（開発用の決まった説明文を生成する）")
+	default:
+		log.Fatalf("[FAIL] DESCRIPTION_GENERATOR=%q は不正な値です（\"openai\" または \"fake\" を設定してください）", config.DescriptionGenerator())
+	}
+
 	if os.Getenv("ALLOWED_ORIGINS") == "" {
 		log.Println("[WARN] ALLOWED_ORIGINS: 未設定（デフォルト値を使用）")
 	} else {
@@ -74,6 +105,7 @@ func main() {
 		AllowOrigins:     allowed,
 		AllowMethods:     []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.PATCH, echo.OPTIONS, echo.HEAD},
 		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, "X-Requested-With"},
+		ExposeHeaders:    []string{"X-Total-Count"},
 		AllowCredentials: true,
 		MaxAge:           600,
 	}))
@@ -114,6 +146,24 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// スタンプの同期 (19時) の後に、説明文がないスタンプと画像が変わったスタンプの説明文を生成する
+	_, err = ss.NewJob(
+		gocron.CronJob("30 19 * * *", false),
+		gocron.NewTask(s.Handler.GenerateDescriptions, context.Background()),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 既存の説明文のスタンプ参照を起動時に作っておく
+	_, err = ss.NewJob(
+		gocron.OneTimeJob(gocron.OneTimeJobStartImmediately()),
+		gocron.NewTask(s.Handler.RebuildDescriptionReferences, context.Background()),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	ss.Start()
 
 	e.Logger.Fatal(e.Start(config.AppAddr()))
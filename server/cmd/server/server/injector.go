@@ -3,9 +3,12 @@ package server
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/contentpolicy"
+	"github.com/traP-jp/1m25_11/server/internal/descgen"
 	"github.com/traP-jp/1m25_11/server/internal/handler"
 	"github.com/traP-jp/1m25_11/server/internal/repository"
 	"github.com/traP-jp/1m25_11/server/pkg/config"
@@ -32,7 +35,30 @@ func Inject(db *sqlx.DB) *Server {
 		}
 	}
 
-	h := handler.New(repo, cache)
+	ngWords, err := config.NGWords()
+	if err != nil {
+		log.Fatalf("ContentPolicy: %v", err)
+	}
+	policy := contentpolicy.New(contentpolicy.Config{
+		DescriptionMaxLength: config.DescriptionMaxLength(),
+		NGWords:              ngWords,
+		MaxLinks:             config.DescriptionMaxLinks(),
+		RateLimit:            config.SubmissionRateLimit(),
+		RateWindow:           time.Minute,
+	})
+
+	var generator descgen.Generator
+	switch config.DescriptionGenerator() {
+	case "":
+	case "openai":
+		generator = descgen.NewOpenAI(config.DescriptionGeneratorBaseURL(), config.DescriptionGeneratorAPIKey(), config.DescriptionGeneratorModel())
+	case "fake":
+		generator = descgen.Fake{}
+	default:
+		log.Fatalf("DescriptionGenerator: unknown generator %q", config.DescriptionGenerator())
+	}
+
+	h := handler.New(repo, cache, policy, generator)
 
 	return &Server{
 		Handler: h,
@@ -0,0 +1,245 @@
+// Package contentpolicy はユーザーが投稿する説明文やタグ名に対する制限 (長さ・NGワード・リンク数・投稿頻度) を扱う。
+package contentpolicy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
+
+// 違反したルールの名前。422 エラーの rule としてクライアントに返す
+const (
+	RuleMaxLength = "max_length"
+	RuleNGWord    = "ng_word"
+	RuleMaxLinks  = "max_links"
+	RuleRateLimit = "rate_limit"
+)
+
+// Kind は投稿の種類。投稿頻度は種類ごとに数える
+type Kind string
+
+const (
+	KindDescription Kind = "description"
+	KindTag         Kind = "tag"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)https?://`)
+
+type (
+	Config struct {
+		// DescriptionMaxLength は説明文の本文の最大文字数
+		DescriptionMaxLength int
+		// NGWords は投稿に含めてはいけない語。正規化してから、英数字だけの語は単語単位で、それ以外は部分一致で調べる
+		NGWords []string
+		// MaxLinks は1つの説明文に含められるリンクの数
+		MaxLinks int
+		// RateLimit は RateWindow の間に1人のユーザーが同じ種類の投稿をできる回数 (0 以下なら制限しない)
+		RateLimit  int
+		RateWindow time.Duration
+	}
+
+	// Violation は投稿がルールに違反していることを表す。422 レスポンスの本文としてそのまま JSON にして返す
+	Violation struct {
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+		// Limit は違反したルールの上限値 (NGワードでは 0)
+		Limit int `json:"limit,omitempty"`
+	}
+
+	Policy struct {
+		config  Config
+		ngWords []string
+
+		mu          sync.Mutex
+		submissions map[submissionKey][]time.Time
+		// prunedAt は期限切れの投稿の記録を最後にまとめて消した時刻
+		prunedAt time.Time
+		now      func() time.Time
+	}
+
+	submissionKey struct {
+		userID uuid.UUID
+		kind   Kind
+	}
+)
+
+func New(config Config) *Policy {
+	ngWords := make([]string, 0, len(config.NGWords))
+	for _, w := range config.NGWords {
+		if n := normalize(w); n != "" {
+			ngWords = append(ngWords, n)
+		}
+	}
+
+	return &Policy{
+		config:      config,
+		ngWords:     ngWords,
+		submissions: map[submissionKey][]time.Time{},
+		now:         time.Now,
+	}
+}
+
+// normalize は NGワードの照合のため、NFKC 正規化して小文字にし、カタカナをひらがなに揃え、記号を取り除き、空白を1つの空白にまとめる。
+// 全角や大文字、間に記号を挟んだ表記でも同じ語として扱える。空白は残すので、隣り合う単語をまたいで一致することはない
+func normalize(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToLower(norm.NFKC.String(s)) {
+		switch {
+		case unicode.IsSpace(r):
+			space = sb.Len() > 0
+
+			continue
+		case unicode.IsPunct(r), unicode.IsSymbol(r), unicode.Is(unicode.Cf, r):
+			continue
+		case 'ァ' <= r && r <= 'ヶ':
+			r -= 'ァ' - 'ぁ'
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+	}
+
+	return sb.String()
+}
+
+// CheckDescription は説明文の本文 description と、構造化された項目などの本文以外のテキスト extra を調べる
+func (p *Policy) CheckDescription(userID uuid.UUID, description string, extra ...string) *Violation {
+	if n := utf8.RuneCountInString(description); p.config.DescriptionMaxLength > 0 && n > p.config.DescriptionMaxLength {
+		return &Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("description must be at most %d characters (got %d)", p.config.DescriptionMaxLength, n),
+			Limit:   p.config.DescriptionMaxLength,
+		}
+	}
+	texts := append([]string{description}, extra...)
+	if v := p.checkNGWords(texts...); v != nil {
+		return v
+	}
+	links := 0
+	for _, t := range texts {
+		links += len(linkPattern.FindAllStringIndex(t, -1))
+	}
+	if p.config.MaxLinks >= 0 && links > p.config.MaxLinks {
+		return &Violation{
+			Rule:    RuleMaxLinks,
+			Message: fmt.Sprintf("description must contain at most %d links (got %d)", p.config.MaxLinks, links),
+			Limit:   p.config.MaxLinks,
+		}
+	}
+
+	return p.checkRate(userID, KindDescription)
+}
+
+// CheckTagName はタグ名を調べる。タグ名の長さは別に検証されている前提
+func (p *Policy) CheckTagName(userID uuid.UUID, name string) *Violation {
+	if v := p.checkNGWords(name); v != nil {
+		return v
+	}
+
+	return p.checkRate(userID, KindTag)
+}
+
+func (p *Policy) checkNGWords(texts ...string) *Violation {
+	for _, t := range texts {
+		// 英数字だけの語は単語単位で照合するため、前後を空白で区切っておく
+		normalized := " " + normalize(t) + " "
+		for _, w := range p.ngWords {
+			if isASCII(w) {
+				w = " " + w + " "
+			}
+			if strings.Contains(normalized, w) {
+				// どの語に一致したかは返さない (NGワードの一覧を探られないようにする)
+				return &Violation{Rule: RuleNGWord, Message: "content contains a prohibited word"}
+			}
+		}
+	}
+
+	return nil
+}
+
+// isASCII は s が ASCII 文字だけからなるかを返す。英語などの空白で区切る言語の語は単語単位で照合し、日本語の語は部分一致で照合する
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkRate は投稿頻度の制限を超えていないかを調べる。投稿は成功したときに Record で記録する。
+// 調べてから記録するまでの間は枠を確保しないので、同じユーザーが同時に送った投稿は上限をわずかに超えて保存されることがある
+func (p *Policy) checkRate(userID uuid.UUID, kind Kind) *Violation {
+	if p.config.RateLimit <= 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.recentSubmissions(submissionKey{userID: userID, kind: kind})) >= p.config.RateLimit {
+		return &Violation{
+			Rule:    RuleRateLimit,
+			Message: fmt.Sprintf("at most %d %s submissions are allowed per %s", p.config.RateLimit, kind, p.config.RateWindow),
+			Limit:   p.config.RateLimit,
+		}
+	}
+
+	return nil
+}
+
+// Record は userID の kind の投稿が保存されたことを記録する。投稿頻度の制限に数えるのは保存できた投稿だけ
+func (p *Policy) Record(userID uuid.UUID, kind Kind) {
+	if p.config.RateLimit <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := submissionKey{userID: userID, kind: kind}
+	p.submissions[key] = append(p.recentSubmissions(key), p.now())
+	p.prune()
+}
+
+// recentSubmissions は RateWindow 内の投稿の時刻を返し、それより古い記録を消す。p.mu を持った状態で呼び出す
+func (p *Policy) recentSubmissions(key submissionKey) []time.Time {
+	now := p.now()
+	recent := p.submissions[key][:0]
+	for _, t := range p.submissions[key] {
+		if now.Sub(t) < p.config.RateWindow {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) == 0 {
+		delete(p.submissions, key)
+
+		return nil
+	}
+	p.submissions[key] = recent
+
+	return recent
+}
+
+// prune は RateWindow ごとに、最近投稿していないユーザーの記録をまとめて消す。p.mu を持った状態で呼び出す
+func (p *Policy) prune() {
+	now := p.now()
+	if now.Sub(p.prunedAt) < p.config.RateWindow {
+		return
+	}
+	p.prunedAt = now
+	for key := range p.submissions {
+		p.recentSubmissions(key)
+	}
+}
@@ -0,0 +1,158 @@
+package contentpolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"ascii", "BadWord", "badword"},
+		{"full-width", "ＢＡＤ　ｗｏｒｄ", "bad word"},
+		{"half-width katakana", "ﾊﾞｶ", "ばか"},
+		{"katakana", "バカ", "ばか"},
+		{"symbol padded", "b.a-d_w*o!r@d", "badword"},
+		{"japanese symbols", "ば・か！", "ばか"},
+		{"zero width", "bad\u200bword", "badword"},
+		{"collapsed spaces", "  bad \t\n word  ", "bad word"},
+		{"symbols only", "!?", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalize(tt.s); got != tt.want {
+				t.Errorf("normalize(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNGWords(t *testing.T) {
+	p := New(Config{NGWords: []string{"ass", "ばか", "Bad Word", "!!!"}})
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{"ascii word", "you ass", true},
+		{"ascii word with punctuation", "ass!", true},
+		{"ascii word full-width", "ＡＳＳ", true},
+		{"ascii inside word", "class assignment", false},
+		{"ascii prefix", "assume", false},
+		{"ascii phrase", "a bad  word here", true},
+		{"ascii phrase across symbols", "bad-word", false},
+		{"ascii phrase split", "badword", false},
+		{"japanese substring", "おまえはばかだ", true},
+		{"japanese katakana", "バカみたい", true},
+		{"japanese symbol padded", "ば・か", true},
+		{"clean", "いいスタンプ", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := p.checkNGWords(tt.text)
+			if got := v != nil; got != tt.want {
+				t.Errorf("checkNGWords(%q) = %v, want violation %v", tt.text, v, tt.want)
+			}
+			if v != nil && v.Rule != RuleNGWord {
+				t.Errorf("checkNGWords(%q).Rule = %q, want %q", tt.text, v.Rule, RuleNGWord)
+			}
+		})
+	}
+}
+
+func TestCheckDescription(t *testing.T) {
+	userID := uuid.New()
+	tests := []struct {
+		name   string
+		config Config
+		text   string
+		extra  []string
+		want   string
+	}{
+		{"ok", Config{DescriptionMaxLength: 5, MaxLinks: 1}, "あいうえお", nil, ""},
+		{"too long", Config{DescriptionMaxLength: 5, MaxLinks: 1}, "あいうえおか", nil, RuleMaxLength},
+		{"unlimited length", Config{MaxLinks: 1}, "あいうえおか", nil, ""},
+		{"links at limit", Config{MaxLinks: 2}, "https://a http://b", nil, ""},
+		{"links over limit", Config{MaxLinks: 2}, "https://a http://b HTTPS://c", nil, RuleMaxLinks},
+		{"links in extra", Config{MaxLinks: 1}, "https://a", []string{"http://b"}, RuleMaxLinks},
+		{"no links allowed", Config{MaxLinks: 0}, "http://a", nil, RuleMaxLinks},
+		{"links unlimited", Config{MaxLinks: -1}, "http://a http://b", nil, ""},
+		{"ng word in extra", Config{MaxLinks: 1, NGWords: []string{"ばか"}}, "ok", []string{"バカ"}, RuleNGWord},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New(tt.config).CheckDescription(userID, tt.text, tt.extra...)
+			got := ""
+			if v != nil {
+				got = v.Rule
+			}
+			if got != tt.want {
+				t.Errorf("CheckDescription(%q) = %+v, want rule %q", tt.text, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := New(Config{NGWords: []string{"ng"}, RateLimit: 2, RateWindow: time.Minute})
+	p.now = func() time.Time { return now }
+	alice, bob := uuid.New(), uuid.New()
+
+	check := func(userID uuid.UUID, name string, want bool) {
+		t.Helper()
+		v := p.CheckTagName(userID, name)
+		if got := v != nil && v.Rule == RuleRateLimit; got != want {
+			t.Errorf("at %s: CheckTagName(%q) = %+v, want rate limited %v", now.Format(time.TimeOnly), name, v, want)
+		}
+	}
+
+	// 記録しない (保存に失敗した) 投稿や違反した投稿は数えない
+	for range 5 {
+		check(alice, "tag", false)
+		p.CheckTagName(alice, "ng")
+	}
+
+	p.Record(alice, KindTag)
+	now = now.Add(30 * time.Second)
+	p.Record(alice, KindTag)
+	check(alice, "tag", true)
+	// ユーザーごと、種類ごとに数える
+	check(bob, "tag", false)
+	if v := p.CheckDescription(alice, "description"); v != nil {
+		t.Errorf("CheckDescription() = %+v, want nil", v)
+	}
+
+	// 1件目が RateWindow を過ぎると1件分空く
+	now = now.Add(30 * time.Second)
+	check(alice, "tag", false)
+	p.Record(alice, KindTag)
+	check(alice, "tag", true)
+
+	// すべて RateWindow を過ぎると記録が消える
+	now = now.Add(2 * time.Minute)
+	p.Record(bob, KindTag)
+	if _, ok := p.submissions[submissionKey{userID: alice, kind: KindTag}]; ok {
+		t.Errorf("expired submissions of alice were not pruned")
+	}
+	check(alice, "tag", false)
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	p := New(Config{})
+	userID := uuid.New()
+	for range 10 {
+		p.Record(userID, KindTag)
+	}
+	if v := p.CheckTagName(userID, "tag"); v != nil {
+		t.Errorf("CheckTagName() = %+v, want nil", v)
+	}
+	if len(p.submissions) != 0 {
+		t.Errorf("submissions = %v, want empty", p.submissions)
+	}
+}
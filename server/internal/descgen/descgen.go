@@ -0,0 +1,44 @@
+// Package descgen はスタンプの説明文を自動で生成する。
+package descgen
+
+import (
+	"context"
+	"fmt"
+)
+
+type (
+	// Generator は説明文を生成する
+	Generator interface {
+		// Model は生成に使うモデルの名前で、生成した説明文の出所として記録する
+		Model() string
+		Generate(ctx context.Context, input Input) (*Result, error)
+	}
+
+	Input struct {
+		StampName string
+		// Image はスタンプの画像 (取得できなかった場合は nil)
+		Image []byte
+		// ImageType は Image の MIME タイプ
+		ImageType string
+	}
+
+	Result struct {
+		Description string `json:"description"`
+		// Confidence は説明文の確からしさ (0〜1)
+		Confidence float64 `json:"confidence"`
+	}
+)
+
+// Fake は外部のサービスを使わずに、スタンプ名だけから決まった説明文を返す。開発やテストで使う
+type Fake struct{}
+
+func (Fake) Model() string {
+	return "fake"
+}
+
+func (Fake) Generate(_ context.Context, input Input) (*Result, error) {
+	return &Result{
+		Description: fmt.Sprintf(":%s: のスタンプです。", input.StampName),
+		Confidence:  0.5,
+	}, nil
+}
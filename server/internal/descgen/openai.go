@@ -0,0 +1,134 @@
+package descgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const openAISystemPrompt = `あなたは traQ (部内チャット) のスタンプ辞典の編集者です。
+与えられたスタンプの名前と画像から、スタンプの見た目・意味・使われる場面を日本語で2〜3文で説明してください。
+わからないことは推測で断定しないでください。
+{"description": 説明文, "confidence": 説明の確からしさ (0〜1 の数)} の形の JSON だけを返してください。`
+
+// OpenAI は OpenAI 互換の Chat Completions API で説明文を生成する
+type OpenAI struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+type (
+	openAIMessage struct {
+		Role    string `json:"role"`
+		Content any    `json:"content"`
+	}
+
+	openAIContentPart struct {
+		Type     string          `json:"type"`
+		Text     string          `json:"text,omitempty"`
+		ImageURL *openAIImageURL `json:"image_url,omitempty"`
+	}
+
+	openAIImageURL struct {
+		URL string `json:"url"`
+	}
+
+	openAIRequest struct {
+		Model          string          `json:"model"`
+		Messages       []openAIMessage `json:"messages"`
+		Temperature    float64         `json:"temperature"`
+		ResponseFormat map[string]any  `json:"response_format"`
+	}
+
+	openAIResponse struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+)
+
+// NewOpenAI は baseURL (例: https://api.openai.com/v1) の API を使う Generator を返す
+func NewOpenAI(baseURL string, apiKey string, model string) *OpenAI {
+	return &OpenAI{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (o *OpenAI) Model() string {
+	return o.model
+}
+
+func (o *OpenAI) Generate(ctx context.Context, input Input) (*Result, error) {
+	parts := []openAIContentPart{{Type: "text", Text: fmt.Sprintf("スタンプ名: :%s:", input.StampName)}}
+	if len(input.Image) > 0 {
+		parts = append(parts, openAIContentPart{
+			Type:     "image_url",
+			ImageURL: &openAIImageURL{URL: "data:" + input.ImageType + ";base64," + base64.StdEncoding.EncodeToString(input.Image)},
+		})
+	}
+	body, err := json.Marshal(openAIRequest{
+		Model: o.model,
+		Messages: []openAIMessage{
+			{Role: "system", Content: openAISystemPrompt},
+			{Role: "user", Content: parts},
+		},
+		Temperature:    0.2,
+		ResponseFormat: map[string]any{"type": "json_object"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request chat completions: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+		return nil, fmt.Errorf("chat completions returned %d: %s", resp.StatusCode, msg)
+	}
+	var completion openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("decode chat completions response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, errors.New("chat completions returned no choices")
+	}
+
+	var result Result
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &result); err != nil {
+		return nil, fmt.Errorf("decode generated description: %w", err)
+	}
+	result.Description = strings.TrimSpace(result.Description)
+	if result.Description == "" {
+		return nil, errors.New("generated description is empty")
+	}
+	result.Confidence = min(max(result.Confidence, 0), 1)
+
+	return &result, nil
+}
@@ -1,39 +1,131 @@
 package handler
 
 import (
+	"errors"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/traP-jp/1m25_11/server/internal/repository"
 )
 
+type bulkResultResponse struct {
+	DryRun bool `json:"dry_run"`
+	// Summary は結果ごとの項目数
+	Summary map[string]int              `json:"summary"`
+	Results []repository.BulkItemResult `json:"results"`
+}
+
+// bulkOptions はクエリパラメータ on_conflict (既定は skip) と dry_run を読む
+func bulkOptions(c echo.Context) (repository.BulkOptions, error) {
+	opts := repository.BulkOptions{OnConflict: repository.BulkConflictSkip}
+	switch policy := repository.BulkConflictPolicy(c.QueryParam("on_conflict")); policy {
+	case "":
+	case repository.BulkConflictSkip, repository.BulkConflictOverwrite, repository.BulkConflictError:
+		opts.OnConflict = policy
+	default:
+		return opts, errors.New("on_conflict must be one of skip, overwrite, error")
+	}
+	if raw := c.QueryParam("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			return opts, errors.New("dry_run must be a boolean")
+		}
+		opts.DryRun = dryRun
+	}
+
+	return opts, nil
+}
+
+// bulkResult は一括登録の結果を返す。on_conflict=error で何も登録しなかった場合は 409
+func bulkResult(c echo.Context, opts repository.BulkOptions, results []repository.BulkItemResult, err error) error {
+	status := http.StatusOK
+	if errors.Is(err, repository.ErrBulkConflict) {
+		status = http.StatusConflict
+	}
+	summary := map[string]int{}
+	for _, result := range results {
+		summary[result.Status]++
+	}
+
+	return c.JSON(status, bulkResultResponse{
+		DryRun:  opts.DryRun,
+		Summary: summary,
+		Results: results,
+	})
+}
+
 func (h *Handler) BulkCreateTags(c echo.Context) error {
+	opts, err := bulkOptions(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	var req []repository.TagInfo
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
-	createdTags, err := h.repo.BulkCreateTags(c.Request().Context(), req)
-	if err != nil {
+
+	// 名前が不正なタグはその項目だけ失敗にし、残りのタグをリポジトリに渡す
+	tags := make([]repository.TagInfo, 0, len(req))
+	indices := make([]int, 0, len(req))
+	var invalid []repository.BulkItemResult
+	for i, tag := range req {
+		name, err := normalizeTagName(tag.Name)
+		if err != nil {
+			invalid = append(invalid, repository.BulkItemResult{
+				Index:   i,
+				Target:  repository.BulkTargetTag,
+				TagName: tag.Name,
+				Status:  repository.BulkStatusError,
+				Reason:  err.Error(),
+			})
+
+			continue
+		}
+		tags = append(tags, repository.TagInfo{Name: name})
+		indices = append(indices, i)
+	}
+	repoOpts := opts
+	if len(invalid) > 0 && opts.OnConflict == repository.BulkConflictError {
+		// 何も登録しないが、残りのタグの結果は返す
+		repoOpts.DryRun = true
+	}
+
+	results, err := h.repo.BulkCreateTags(c.Request().Context(), tags, repoOpts)
+	if err != nil && !errors.Is(err, repository.ErrBulkConflict) {
 		log.Printf("error in BulkCreateTags repository call: %v", err)
 
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to create tags")
 	}
+	for i := range results {
+		results[i].Index = indices[results[i].Index]
+	}
+	results = append(results, invalid...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+	if len(invalid) > 0 && opts.OnConflict == repository.BulkConflictError {
+		err = repository.ErrBulkConflict
+	}
 
-	return c.JSON(http.StatusCreated, createdTags)
+	return bulkResult(c, opts, results, err)
 }
 
 func (h *Handler) BulkAddStampMeta(c echo.Context) error {
+	opts, err := bulkOptions(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	var req []repository.StampMetaAddition
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
 	}
-	err := h.repo.BulkAddStampMeta(c.Request().Context(), req)
-	if err != nil {
+	results, err := h.repo.BulkAddStampMeta(c.Request().Context(), req, opts)
+	if err != nil && !errors.Is(err, repository.ErrBulkConflict) {
 		log.Printf("error in BulkAddStampMeta repository call: %v", err)
 
 		return echo.NewHTTPError(http.StatusInternalServerError, "failed to link tags and add descriptions")
 	}
 
-	return c.NoContent(http.StatusNoContent)
+	return bulkResult(c, opts, results, err)
 }
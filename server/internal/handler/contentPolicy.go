@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+// descriptionFieldTexts はコンテンツポリシーで本文と合わせて調べる、構造化された項目のテキストを返す
+func descriptionFieldTexts(fields repository.DescriptionFieldsUpdate) []string {
+	texts := []string{}
+	for _, f := range []*string{fields.Meaning, fields.Usage, fields.Origin} {
+		if f != nil {
+			texts = append(texts, *f)
+		}
+	}
+	if fields.Examples != nil {
+		texts = append(texts, *fields.Examples...)
+	}
+
+	return texts
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/contentpolicy"
+)
+
+func TestContentPolicyViolationResponse(t *testing.T) {
+	h := &Handler{policy: contentpolicy.New(contentpolicy.Config{
+		DescriptionMaxLength: 10,
+		NGWords:              []string{"badword"},
+		MaxLinks:             1,
+	})}
+	stampID := uuid.NewString()
+	tagID := uuid.NewString()
+
+	tests := []struct {
+		name    string
+		method  string
+		handler echo.HandlerFunc
+		param   [2]string
+		body    string
+		want    contentpolicy.Violation
+	}{
+		{
+			"create description too long",
+			http.MethodPost,
+			h.createDescriptions,
+			[2]string{"stampId", stampID},
+			`{"description":"01234567890"}`,
+			contentpolicy.Violation{Rule: contentpolicy.RuleMaxLength, Message: "description must be at most 10 characters (got 11)", Limit: 10},
+		},
+		{
+			"update description with too many links",
+			http.MethodPut,
+			h.updateDescriptions,
+			[2]string{"stampId", stampID},
+			`{"description":"http://a","meaning":"http://b"}`,
+			contentpolicy.Violation{Rule: contentpolicy.RuleMaxLinks, Message: "description must contain at most 1 links (got 2)", Limit: 1},
+		},
+		{
+			"create tag with NG word",
+			http.MethodPost,
+			h.createTags,
+			[2]string{},
+			`{"name":"BADWORD"}`,
+			contentpolicy.Violation{Rule: contentpolicy.RuleNGWord, Message: "content contains a prohibited word"},
+		},
+		{
+			"update tag with NG word",
+			http.MethodPut,
+			h.updateTags,
+			[2]string{"tagId", tagID},
+			`{"name":"badword"}`,
+			contentpolicy.Violation{Rule: contentpolicy.RuleNGWord, Message: "content contains a prohibited word"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames(tt.param[0])
+			c.SetParamValues(tt.param[1])
+			c.Set(userIDContextKey, uuid.New())
+
+			if err := tt.handler(c); err != nil {
+				e.HTTPErrorHandler(err, c)
+			}
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+			}
+			var got contentpolicy.Violation
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("unmarshal body %s: %v", rec.Body, err)
+			}
+			if got != tt.want {
+				t.Errorf("body = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -17,10 +18,11 @@ import (
 
 var traqHTTPClient = &http.Client{Timeout: 10 * time.Second}
 
-// UserCache は traQ ID → UUID のインメモリキャッシュ
+// UserCache は traQ ID ⇔ UUID のインメモリキャッシュ
 type UserCache struct {
 	mu           sync.RWMutex
 	traqIDToUUID map[string]uuid.UUID
+	uuidToTraqID map[uuid.UUID]string
 }
 
 type traqUser struct {
@@ -56,14 +58,17 @@ func (uc *UserCache) Refresh(botToken string) error {
 	}
 
 	newMap := make(map[string]uuid.UUID, len(users))
+	reverseMap := make(map[uuid.UUID]string, len(users))
 	for _, u := range users {
 		if !u.Bot {
 			newMap[strings.ToLower(u.Name)] = u.ID
+			reverseMap[u.ID] = u.Name
 		}
 	}
 
 	uc.mu.Lock()
 	uc.traqIDToUUID = newMap
+	uc.uuidToTraqID = reverseMap
 	uc.mu.Unlock()
 
 	log.Printf("UserCache: refreshed %d users", len(newMap))
@@ -80,6 +85,15 @@ func (uc *UserCache) GetUUID(traqID string) (uuid.UUID, bool) {
 	return id, ok
 }
 
+// GetTraqID は UUID から traQ ID を返す
+func (uc *UserCache) GetTraqID(id uuid.UUID) (string, bool) {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	name, ok := uc.uuidToTraqID[id]
+
+	return name, ok
+}
+
 // Size はキャッシュに登録されているユーザー数を返す
 func (uc *UserCache) Size() int {
 	uc.mu.RLock()
@@ -127,3 +141,16 @@ func (h *Handler) getUserID(c echo.Context) (uuid.UUID, error) {
 
 	return id, nil
 }
+
+// resolveUserID は UUID または traQ ID で指定されたユーザーを UUID に変換する
+func (h *Handler) resolveUserID(s string) (uuid.UUID, error) {
+	if id, err := uuid.Parse(s); err == nil {
+		return id, nil
+	}
+	id, ok := h.userCache.GetUUID(strings.ToLower(s))
+	if !ok {
+		return uuid.Nil, errors.New("user not found in cache")
+	}
+
+	return id, nil
+}
@@ -3,14 +3,22 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/contentpolicy"
 	"github.com/traP-jp/1m25_11/server/internal/repository"
 )
 
+// descriptionPayload は説明文の本文と、任意の構造化された項目。Lang を省略すると既定の言語の説明文になる
 type descriptionPayload struct {
-	Description string `json:"description"`
+	Lang        string    `json:"lang"`
+	Description string    `json:"description"`
+	Meaning     *string   `json:"meaning"`
+	Usage       *string   `json:"usage"`
+	Origin      *string   `json:"origin"`
+	Examples    *[]string `json:"examples"`
 }
 
 func (h *Handler) createDescriptions(c echo.Context) error {
@@ -29,10 +37,30 @@ func (h *Handler) createDescriptions(c echo.Context) error {
 	if payload.Description == "" {
 		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(errors.New("description cannot be empty"))
 	}
+	lang, err := normalizeDescriptionLang(payload.Lang)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	fields, err := payload.fieldsUpdate()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	unknown, err := h.unknownStampReferences(c.Request().Context(), payload.Description)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	if len(unknown) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "unknown stamp references: "+strings.Join(unknown, ", "))
+	}
+	if v := h.policy.CheckDescription(creatorID, payload.Description, descriptionFieldTexts(fields)...); v != nil {
+		return c.JSON(http.StatusUnprocessableEntity, v)
+	}
 	err = h.repo.CreateDescriptions(c.Request().Context(), repository.CreateDescriptionParams{
-		StampID:     stampID,
-		Description: payload.Description,
-		CreatorID:   creatorID,
+		StampID:           stampID,
+		Description:       payload.Description,
+		CreatorID:         creatorID,
+		Lang:              lang,
+		DescriptionFields: descriptionFields(fields),
 	})
 	if err != nil {
 		if errors.Is(err, repository.ErrStampNotFound) {
@@ -47,15 +75,21 @@ func (h *Handler) createDescriptions(c echo.Context) error {
 
 		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 	}
+	h.policy.Record(creatorID, contentpolicy.KindDescription)
 
 	return c.NoContent(http.StatusCreated)
 }
 
+// getDescriptions は lang パラメータか Accept-Language で選んだ言語の説明文を返す。選んだ言語は Content-Language に入れる
 func (h *Handler) getDescriptions(c echo.Context) error {
 	stampID, err := uuid.Parse(c.Param("stampId"))
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
 	}
+	prefs, err := preferredDescriptionLangs(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	descriptions, err := h.repo.GetDescriptionsByStampID(c.Request().Context(), stampID)
 	if err != nil {
 		if errors.Is(err, repository.ErrStampNotFound) {
@@ -67,6 +101,13 @@ func (h *Handler) getDescriptions(c echo.Context) error {
 
 		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 	}
+	lang, descriptions, _ := selectDescriptionLang(descriptions, prefs)
+	if err := h.renderDescriptions(c.Request().Context(), descriptions); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	if lang != nil {
+		c.Response().Header().Set("Content-Language", *lang)
+	}
 
 	return c.JSON(http.StatusOK, descriptions)
 }
@@ -87,7 +128,25 @@ func (h *Handler) updateDescriptions(c echo.Context) error {
 	if payload.Description == "" {
 		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(errors.New("description cannot be empty"))
 	}
-	if err = h.repo.UpdateDescriptions(c.Request().Context(), stampID, creatorID, payload.Description); err != nil {
+	lang, err := normalizeDescriptionLang(payload.Lang)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	fields, err := payload.fieldsUpdate()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	unknown, err := h.unknownStampReferences(c.Request().Context(), payload.Description)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	if len(unknown) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "unknown stamp references: "+strings.Join(unknown, ", "))
+	}
+	if v := h.policy.CheckDescription(creatorID, payload.Description, descriptionFieldTexts(fields)...); v != nil {
+		return c.JSON(http.StatusUnprocessableEntity, v)
+	}
+	if err = h.repo.UpdateDescriptions(c.Request().Context(), stampID, creatorID, lang, payload.Description, fields); err != nil {
 		if errors.Is(err, repository.ErrDescriptionNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound).SetInternal(err)
 		}
@@ -100,6 +159,7 @@ func (h *Handler) updateDescriptions(c echo.Context) error {
 
 		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 	}
+	h.policy.Record(creatorID, contentpolicy.KindDescription)
 
 	return c.NoContent(http.StatusNoContent)
 }
@@ -113,7 +173,11 @@ func (h *Handler) deleteDescriptions(c echo.Context) error {
 	if !ok {
 		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
 	}
-	if err = h.repo.DeleteDescriptions(c.Request().Context(), stampID, creatorID); err != nil {
+	lang, err := normalizeDescriptionLang(c.QueryParam("lang"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err = h.repo.DeleteDescriptions(c.Request().Context(), stampID, creatorID, lang); err != nil {
 		if errors.Is(err, repository.ErrDescriptionNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound).SetInternal(err)
 		}
@@ -129,3 +193,70 @@ func (h *Handler) deleteDescriptions(c echo.Context) error {
 
 	return c.NoContent(http.StatusNoContent)
 }
+
+type descriptionVotePayload struct {
+	Value int `json:"value"`
+}
+
+// voteDescription は他のユーザーが書いた説明文に役に立つ (1) か役に立たない (-1) の票を入れる
+func (h *Handler) voteDescription(c echo.Context) error {
+	stampID, err := uuid.Parse(c.Param("stampId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	creatorID, err := uuid.Parse(c.Param("creatorId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	lang, err := normalizeDescriptionLang(c.QueryParam("lang"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	voterID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	payload := new(descriptionVotePayload)
+	if err = c.Bind(payload); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	if payload.Value != 1 && payload.Value != -1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "value must be 1 or -1")
+	}
+	if err = h.repo.VoteDescription(c.Request().Context(), stampID, creatorID, lang, voterID, payload.Value); err != nil {
+		if errors.Is(err, repository.ErrDescriptionNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound).SetInternal(err)
+		}
+		if errors.Is(err, repository.ErrCannotVoteOwnDescription) {
+			return echo.NewHTTPError(http.StatusForbidden, "cannot vote on your own description").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) deleteDescriptionVote(c echo.Context) error {
+	stampID, err := uuid.Parse(c.Param("stampId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	creatorID, err := uuid.Parse(c.Param("creatorId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	lang, err := normalizeDescriptionLang(c.QueryParam("lang"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	voterID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	if err = h.repo.DeleteDescriptionVote(c.Request().Context(), stampID, creatorID, lang, voterID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+const (
+	descriptionFieldMaxLength   = 1000
+	descriptionExampleMaxLength = 200
+	descriptionExamplesMaxCount = 10
+)
+
+// normalizeDescriptionField は構造化された項目の前後の空白を取り除いて長さを確かめる。nil は nil のまま返す
+func normalizeDescriptionField(name string, value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if utf8.RuneCountInString(trimmed) > descriptionFieldMaxLength {
+		return nil, fmt.Errorf("%s must be at most %d characters", name, descriptionFieldMaxLength)
+	}
+
+	return &trimmed, nil
+}
+
+// normalizeDescriptionExamples は例文の前後の空白を取り除き、空の例文を除いて件数と長さを確かめる。例文は1行でなければならない
+func normalizeDescriptionExamples(examples *[]string) (*repository.DescriptionExamples, error) {
+	if examples == nil {
+		return nil, nil
+	}
+	normalized := repository.DescriptionExamples{}
+	for _, e := range *examples {
+		e = strings.TrimSpace(e)
+		if e == "" {
+			continue
+		}
+		if strings.ContainsAny(e, "\r\n") {
+			return nil, errors.New("each example must be a single line")
+		}
+		if utf8.RuneCountInString(e) > descriptionExampleMaxLength {
+			return nil, fmt.Errorf("each example must be at most %d characters", descriptionExampleMaxLength)
+		}
+		normalized = append(normalized, e)
+	}
+	if len(normalized) > descriptionExamplesMaxCount {
+		return nil, fmt.Errorf("examples must be at most %d items", descriptionExamplesMaxCount)
+	}
+
+	return &normalized, nil
+}
+
+// fieldsUpdate は payload の構造化された項目を検証する。送られなかった項目は nil のまま (更新では変更しない)
+func (p *descriptionPayload) fieldsUpdate() (repository.DescriptionFieldsUpdate, error) {
+	var update repository.DescriptionFieldsUpdate
+	var err error
+	if update.Meaning, err = normalizeDescriptionField("meaning", p.Meaning); err != nil {
+		return repository.DescriptionFieldsUpdate{}, err
+	}
+	if update.Usage, err = normalizeDescriptionField("usage", p.Usage); err != nil {
+		return repository.DescriptionFieldsUpdate{}, err
+	}
+	if update.Origin, err = normalizeDescriptionField("origin", p.Origin); err != nil {
+		return repository.DescriptionFieldsUpdate{}, err
+	}
+	if update.Examples, err = normalizeDescriptionExamples(p.Examples); err != nil {
+		return repository.DescriptionFieldsUpdate{}, err
+	}
+
+	return update, nil
+}
+
+// descriptionFields は新しく作る説明文の構造化された項目を返す。空の項目は未設定にする
+func descriptionFields(update repository.DescriptionFieldsUpdate) repository.DescriptionFields {
+	nonEmpty := func(s *string) *string {
+		if s == nil || *s == "" {
+			return nil
+		}
+
+		return s
+	}
+	fields := repository.DescriptionFields{
+		Meaning:  nonEmpty(update.Meaning),
+		Usage:    nonEmpty(update.Usage),
+		Origin:   nonEmpty(update.Origin),
+		Examples: repository.DescriptionExamples{},
+	}
+	if update.Examples != nil {
+		fields.Examples = *update.Examples
+	}
+
+	return fields
+}
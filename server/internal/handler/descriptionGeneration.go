@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/traP-jp/1m25_11/server/internal/descgen"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+	"github.com/traP-jp/1m25_11/server/pkg/config"
+)
+
+// traqFileMaxSize は説明文の生成のために取得するスタンプ画像の最大サイズ
+const traqFileMaxSize = 2 << 20
+
+// fetchTraqFile は traQ のファイルの中身と MIME タイプを返す
+func fetchTraqFile(ctx context.Context, botToken string, fileID uuid.UUID) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, traqAPIBaseURL+"/files/"+fileID.String(), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+botToken)
+
+	resp, err := traqHTTPClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("request file %s: %w", fileID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("traQ API returned %d for file %s", resp.StatusCode, fileID)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, traqFileMaxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("read file %s: %w", fileID, err)
+	}
+	if len(data) > traqFileMaxSize {
+		return nil, "", fmt.Errorf("file %s is too large", fileID)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	return data, contentType, nil
+}
+
+// GenerateDescriptions は説明文がないスタンプと画像が変わったスタンプの説明文を生成する。生成器が設定されていなければ何もしない
+func (h *Handler) GenerateDescriptions(ctx context.Context) {
+	if h.generator == nil {
+		return
+	}
+	targets, err := h.repo.GetDescriptionGenerationQueue(ctx, config.DescriptionGenerationBatchSize())
+	if err != nil {
+		log.Printf("Error retrieving description generation queue: %v", err)
+
+		return
+	}
+	botToken := os.Getenv("BOT_TOKEN_KEY")
+
+	generated := 0
+	for _, t := range targets {
+		input := descgen.Input{StampName: t.Name}
+		// 画像が取得できなくてもスタンプ名だけで生成する
+		if botToken != "" {
+			if input.Image, input.ImageType, err = fetchTraqFile(ctx, botToken, t.FileID); err != nil {
+				log.Printf("Error fetching stamp image (%s): %v", t.ID, err)
+			}
+		}
+		result, err := h.generator.Generate(ctx, input)
+		if err != nil {
+			log.Printf("Error generating description (%s): %v", t.ID, err)
+			// 失敗したスタンプは間隔を空けて生成し直し、後ろのスタンプを先に生成する
+			if err := h.repo.RecordDescriptionGenerationFailure(ctx, t.ID, t.FileID, err.Error()); err != nil {
+				log.Printf("Error recording description generation failure (%s): %v", t.ID, err)
+			}
+
+			continue
+		}
+		if err := h.repo.SaveMachineDescription(ctx, repository.MachineDescription{
+			StampID:     t.ID,
+			FileID:      t.FileID,
+			Description: result.Description,
+			Model:       h.generator.Model(),
+			Confidence:  result.Confidence,
+		}); err != nil {
+			log.Printf("Error saving generated description (%s): %v", t.ID, err)
+
+			continue
+		}
+		generated++
+	}
+	log.Printf("Generated descriptions for %d/%d stamps with %s", generated, len(targets), h.generator.Model())
+}
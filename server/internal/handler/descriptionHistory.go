@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+// 行単位の差分の種類
+const (
+	diffOpEqual  = "equal"
+	diffOpInsert = "insert"
+	diffOpDelete = "delete"
+)
+
+type (
+	getDescriptionHistoryParams struct {
+		CreatorID *string `query:"creator_id"`
+		Lang      *string `query:"lang"`
+	}
+
+	DiffLine struct {
+		Op   string `json:"op"`
+		Line string `json:"line"`
+	}
+
+	DescriptionRevision struct {
+		CreatorId   uuid.UUID `json:"creator_id"`
+		Lang        string    `json:"lang"`
+		Revision    int       `json:"revision"`
+		Action      string    `json:"action"`
+		Description *string   `json:"description"`
+		repository.DescriptionFields
+		EditorId  uuid.UUID  `json:"editor_id"`
+		CreatedAt time.Time  `json:"created_at"`
+		Diff      []DiffLine `json:"diff"`
+	}
+
+	PostStampsStampIdDescriptionsRollbackJSONRequestBody struct {
+		CreatorID uuid.UUID `json:"creator_id"`
+		Lang      string    `json:"lang"`
+		Revision  int       `json:"revision"`
+	}
+)
+
+// splitLines は本文を行に分ける。nil (削除された状態) と空文字列はどちらも0行とする
+func splitLines(text *string) []string {
+	if text == nil || *text == "" {
+		return []string{}
+	}
+
+	return strings.Split(strings.ReplaceAll(*text, "\r\n", "\n"), "\n")
+}
+
+// revisionLines は版の本文と構造化された項目を差分用の行にする。項目の行には項目名を付ける。削除された版は0行とする
+func revisionLines(description *string, fields repository.DescriptionFields) []string {
+	if description == nil {
+		return []string{}
+	}
+	lines := splitLines(description)
+	labeled := []struct {
+		label string
+		value *string
+	}{
+		{"meaning", fields.Meaning},
+		{"usage", fields.Usage},
+		{"origin", fields.Origin},
+	}
+	for _, field := range labeled {
+		for _, line := range splitLines(field.value) {
+			lines = append(lines, "["+field.label+"] "+line)
+		}
+	}
+	for _, example := range fields.Examples {
+		lines = append(lines, "[examples] "+example)
+	}
+
+	return lines
+}
+
+// diffLines は最長共通部分列による before から after への行単位の差分を返す
+func diffLines(before []string, after []string) []DiffLine {
+	// lcs[i][j] は before[i:] と after[j:] の最長共通部分列の長さ
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := []DiffLine{}
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			diff = append(diff, DiffLine{Op: diffOpEqual, Line: before[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, DiffLine{Op: diffOpDelete, Line: before[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: diffOpInsert, Line: after[j]})
+			j++
+		}
+	}
+	for ; i < len(before); i++ {
+		diff = append(diff, DiffLine{Op: diffOpDelete, Line: before[i]})
+	}
+	for ; j < len(after); j++ {
+		diff = append(diff, DiffLine{Op: diffOpInsert, Line: after[j]})
+	}
+
+	return diff
+}
+
+// getDescriptionHistory はスタンプの説明文の版を新しい順に、同じユーザーの説明文の1つ前の版との差分付きで返す
+func (h *Handler) getDescriptionHistory(c echo.Context) error {
+	stampID, err := uuid.Parse(c.Param("stampId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	var params getDescriptionHistoryParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+	var creatorID *uuid.UUID
+	if params.CreatorID != nil {
+		id, err := h.resolveUserID(*params.CreatorID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "unknown creator_id").SetInternal(err)
+		}
+		creatorID = &id
+	}
+	var lang *string
+	if params.Lang != nil {
+		l, err := normalizeDescriptionLang(*params.Lang)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		lang = &l
+	}
+
+	// 非表示にされた説明文の内容は、管理者以外には履歴からも見せない
+	userID, _ := c.Get(userIDContextKey).(uuid.UUID)
+	revisions, err := h.repo.GetDescriptionRevisions(c.Request().Context(), stampID, creatorID, lang, h.isAdmin(userID))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	history := make([]DescriptionRevision, len(revisions))
+	for i, r := range revisions {
+		previous := []string{}
+		if i > 0 && revisions[i-1].CreatorID == r.CreatorID && revisions[i-1].Lang == r.Lang {
+			previous = revisionLines(revisions[i-1].Description, revisions[i-1].DescriptionFields)
+		}
+		history[i] = DescriptionRevision{
+			CreatorId:         r.CreatorID,
+			Lang:              r.Lang,
+			Revision:          r.Revision,
+			Action:            r.Action,
+			Description:       r.Description,
+			DescriptionFields: r.DescriptionFields,
+			EditorId:          r.EditorID,
+			CreatedAt:         r.CreatedAt,
+			Diff:              diffLines(previous, revisionLines(r.Description, r.DescriptionFields)),
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt.After(history[j].CreatedAt)
+	})
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// rollbackDescription は説明文を過去の版に戻す。説明文を書いたユーザーか管理者のみ実行できる。
+func (h *Handler) rollbackDescription(c echo.Context) error {
+	stampID, err := uuid.Parse(c.Param("stampId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	var body PostStampsStampIdDescriptionsRollbackJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	if body.CreatorID != userID && !h.isAdmin(userID) {
+		return echo.NewHTTPError(http.StatusForbidden, "only the author or an admin can roll back this description")
+	}
+	lang, err := normalizeDescriptionLang(body.Lang)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.repo.RollbackDescription(c.Request().Context(), stampID, body.CreatorID, lang, body.Revision, userID); err != nil {
+		if errors.Is(err, repository.ErrDescriptionRevisionNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "revision not found").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
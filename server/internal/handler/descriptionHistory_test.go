@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name string
+		text *string
+		want []string
+	}{
+		{"nil", nil, []string{}},
+		{"empty", ptr(""), []string{}},
+		{"single line", ptr("a"), []string{"a"}},
+		{"multiple lines", ptr("a\nb"), []string{"a", "b"}},
+		{"crlf", ptr("a\r\nb"), []string{"a", "b"}},
+		{"trailing newline", ptr("a\n"), []string{"a", ""}},
+		{"blank line", ptr("a\n\nb"), []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevisionLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		description *string
+		fields      repository.DescriptionFields
+		want        []string
+	}{
+		{"deleted", nil, repository.DescriptionFields{Meaning: ptr("m")}, []string{}},
+		{"description only", ptr("a\nb"), repository.DescriptionFields{}, []string{"a", "b"}},
+		{
+			"all fields",
+			ptr("a"),
+			repository.DescriptionFields{
+				Meaning:  ptr("m1\nm2"),
+				Usage:    ptr("u"),
+				Origin:   ptr("o"),
+				Examples: repository.DescriptionExamples{"e1", "e2"},
+			},
+			[]string{"a", "[meaning] m1", "[meaning] m2", "[usage] u", "[origin] o", "[examples] e1", "[examples] e2"},
+		},
+		{"empty field", ptr("a"), repository.DescriptionFields{Origin: ptr("")}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := revisionLines(tt.description, tt.fields); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("revisionLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	eq := func(line string) DiffLine { return DiffLine{Op: diffOpEqual, Line: line} }
+	ins := func(line string) DiffLine { return DiffLine{Op: diffOpInsert, Line: line} }
+	del := func(line string) DiffLine { return DiffLine{Op: diffOpDelete, Line: line} }
+
+	tests := []struct {
+		name   string
+		before []string
+		after  []string
+		want   []DiffLine
+	}{
+		{"both empty", []string{}, []string{}, []DiffLine{}},
+		{"created", []string{}, []string{"a", "b"}, []DiffLine{ins("a"), ins("b")}},
+		{"deleted", []string{"a", "b"}, []string{}, []DiffLine{del("a"), del("b")}},
+		{"identical", []string{"a", "b"}, []string{"a", "b"}, []DiffLine{eq("a"), eq("b")}},
+		{"all changed", []string{"a", "b"}, []string{"c", "d"}, []DiffLine{del("a"), del("b"), ins("c"), ins("d")}},
+		{"line changed", []string{"a", "b", "c"}, []string{"a", "x", "c"}, []DiffLine{eq("a"), del("b"), ins("x"), eq("c")}},
+		{"line inserted", []string{"a", "c"}, []string{"a", "b", "c"}, []DiffLine{eq("a"), ins("b"), eq("c")}},
+		{"line removed", []string{"a", "b", "c"}, []string{"a", "c"}, []DiffLine{eq("a"), del("b"), eq("c")}},
+		{"trailing newline added", []string{"a"}, []string{"a", ""}, []DiffLine{eq("a"), ins("")}},
+		{"duplicate lines", []string{"a", "a"}, []string{"a"}, []DiffLine{eq("a"), del("a")}},
+		{"field changed", []string{"a", "[meaning] x"}, []string{"a", "[meaning] y"}, []DiffLine{eq("a"), del("[meaning] x"), ins("[meaning] y")}},
+		{"moved line", []string{"a", "b"}, []string{"b", "a"}, []DiffLine{del("a"), eq("b"), ins("a")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffLines(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines(%q, %q) = %v, want %v", tt.before, tt.after, got, tt.want)
+			}
+		})
+	}
+}
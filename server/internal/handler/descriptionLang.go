@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+	"golang.org/x/text/language"
+)
+
+// supportedDescriptionLangs は説明文を書ける言語。先頭が既定の言語
+var supportedDescriptionLangs = []string{repository.DefaultDescriptionLang, "en"}
+
+// descriptionLangOf は言語タグを説明文の言語コードにする。en-US のような地域付きのタグは言語の部分だけを使う
+func descriptionLangOf(tag language.Tag) (string, bool) {
+	base, _ := tag.Base()
+	lang := base.String()
+
+	return lang, slices.Contains(supportedDescriptionLangs, lang)
+}
+
+// normalizeDescriptionLang はリクエストで指定された言語コードを検証する。空なら既定の言語にする
+func normalizeDescriptionLang(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return repository.DefaultDescriptionLang, nil
+	}
+	tag, err := language.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid lang: %q", raw)
+	}
+	lang, ok := descriptionLangOf(tag)
+	if !ok {
+		return "", fmt.Errorf("lang must be one of %s", strings.Join(supportedDescriptionLangs, ", "))
+	}
+
+	return lang, nil
+}
+
+// preferredDescriptionLangs はクライアントが読みたい説明文の言語を優先度の高い順に返す。
+// lang パラメータがあればそれを最優先にし、続けて Accept-Language の言語を q 値の順に並べる。対応していない言語は無視する
+func preferredDescriptionLangs(c echo.Context) ([]string, error) {
+	langs := []string{}
+	if raw := c.QueryParam("lang"); raw != "" {
+		lang, err := normalizeDescriptionLang(raw)
+		if err != nil {
+			return nil, err
+		}
+		langs = append(langs, lang)
+	}
+	tags, _, _ := language.ParseAcceptLanguage(c.Request().Header.Get("Accept-Language"))
+	for _, tag := range tags {
+		if lang, ok := descriptionLangOf(tag); ok && !slices.Contains(langs, lang) {
+			langs = append(langs, lang)
+		}
+	}
+
+	return langs, nil
+}
+
+// selectDescriptionLang は説明文のある言語のうち prefs で最も優先される言語を選び、その言語の説明文 (順序はそのまま) と説明文のある言語の一覧を返す。
+// prefs の言語の説明文がなければ既定の言語、それもなければ説明文のある最初の言語にフォールバックする。説明文がひとつもなければ言語は nil
+func selectDescriptionLang(descriptions []*repository.StampDescription, prefs []string) (*string, []*repository.StampDescription, []string) {
+	if len(descriptions) == 0 {
+		return nil, []*repository.StampDescription{}, []string{}
+	}
+
+	available := []string{}
+	for _, lang := range supportedDescriptionLangs {
+		if slices.ContainsFunc(descriptions, func(d *repository.StampDescription) bool { return d.Lang == lang }) {
+			available = append(available, lang)
+		}
+	}
+
+	selected := ""
+	for _, lang := range append(slices.Clone(prefs), repository.DefaultDescriptionLang) {
+		if slices.Contains(available, lang) {
+			selected = lang
+
+			break
+		}
+	}
+	if selected == "" {
+		selected = available[0]
+	}
+
+	filtered := []*repository.StampDescription{}
+	for _, d := range descriptions {
+		if d.Lang == selected {
+			filtered = append(filtered, d)
+		}
+	}
+
+	return &selected, filtered, available
+}
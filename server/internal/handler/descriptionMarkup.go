@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/traP-jp/1m25_11/server/internal/markup"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+// referencedStamps は本文中のスタンプ参照のうち存在するスタンプを名前で引けるようにして返す
+func (h *Handler) referencedStamps(ctx context.Context, sources ...string) (map[string]markup.Stamp, error) {
+	names := []string{}
+	for _, src := range sources {
+		names = append(names, markup.ExtractStampNames(src)...)
+	}
+	stamps, err := h.repo.GetStampsByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]markup.Stamp, len(stamps))
+	for _, s := range stamps {
+		referenced[s.Name] = markup.Stamp{ID: s.ID, FileID: s.FileID}
+	}
+
+	return referenced, nil
+}
+
+// unknownStampReferences は説明文が参照しているスタンプのうち存在しないものを ":name:" の形で返す
+func (h *Handler) unknownStampReferences(ctx context.Context, description string) ([]string, error) {
+	referenced, err := h.referencedStamps(ctx, description)
+	if err != nil {
+		return nil, err
+	}
+	unknown := []string{}
+	for _, name := range markup.ExtractStampNames(description) {
+		if _, ok := referenced[name]; !ok {
+			unknown = append(unknown, ":"+name+":")
+		}
+	}
+
+	return unknown, nil
+}
+
+// renderDescriptions は説明文の HTML を描画して DescriptionHTML に設定する
+func (h *Handler) renderDescriptions(ctx context.Context, descriptions []*repository.StampDescription) error {
+	sources := make([]string, len(descriptions))
+	for i, d := range descriptions {
+		sources[i] = d.Description
+	}
+	referenced, err := h.referencedStamps(ctx, sources...)
+	if err != nil {
+		return err
+	}
+	for _, d := range descriptions {
+		d.DescriptionHTML = markup.Render(d.Description, referenced)
+	}
+
+	return nil
+}
@@ -12,17 +12,24 @@ import (
 
 type (
 	DetailResponse struct {
-		ID           uuid.UUID                      `json:"stamp_id"`
-		Name         string                         `json:"stamp_name"`
-		FileID       uuid.UUID                      `json:"file_id"`
-		CreatorID    uuid.UUID                      `json:"creator_id"`
-		IsUnicode    bool                           `json:"is_unicode"`
-		CreatedAt    time.Time                      `json:"created_at"`
-		UpdatedAt    time.Time                      `json:"updated_at"`
-		CountMonthly int                            `json:"count_monthly"`
-		CountTotal   int64                          `json:"count_total"`
-		Descriptions []*repository.StampDescription `json:"descriptions"`
-		Tags         []*repository.TagSummary       `json:"tags"`
+		ID             uuid.UUID                      `json:"stamp_id"`
+		Name           string                         `json:"stamp_name"`
+		FileID         uuid.UUID                      `json:"file_id"`
+		CreatorID      uuid.UUID                      `json:"creator_id"`
+		IsUnicode      bool                           `json:"is_unicode"`
+		CreatedAt      time.Time                      `json:"created_at"`
+		UpdatedAt      time.Time                      `json:"updated_at"`
+		CountMonthly   int                            `json:"count_monthly"`
+		CountTotal     int64                          `json:"count_total"`
+		Descriptions   []*repository.StampDescription `json:"descriptions"`
+		TopDescription *repository.StampDescription   `json:"top_description"`
+		// DescriptionLang は Descriptions の言語 (説明文がなければ nil) で、AvailableDescriptionLangs は説明文がある言語の一覧
+		DescriptionLang           *string                           `json:"description_lang"`
+		AvailableDescriptionLangs []string                          `json:"available_description_langs"`
+		Tags                      []*repository.TagSummary          `json:"tags"`
+		TagGroups                 []*TagGroup                       `json:"tag_groups"`
+		MentionedIn               []*repository.MentioningStamp     `json:"mentioned_in"`
+		Examples                  []*repository.StampMessageExample `json:"examples"`
 	}
 )
 
@@ -42,27 +49,60 @@ func (h *Handler) getDetails(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get stamp details").SetInternal(err)
 	}
 
+	prefs, err := preferredDescriptionLangs(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
 	descriptions, err := h.repo.GetDescriptionsByStampID(c.Request().Context(), stampID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 	}
+	lang, descriptions, availableLangs := selectDescriptionLang(descriptions, prefs)
+	if err := h.renderDescriptions(c.Request().Context(), descriptions); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	mentionedIn, err := h.repo.GetMentioningStamps(c.Request().Context(), stampID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	examples, err := h.repo.GetStampExamples(c.Request().Context(), stampID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
 
-	tags, err := h.repo.GetTagsByStampID(c.Request().Context(), stampID)
+	categorizedTags, err := h.repo.GetCategorizedTagsByStampID(c.Request().Context(), stampID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 	}
+	tagGroups := groupTagsByCategory(categorizedTags)
+	tags := []*repository.TagSummary{}
+	for _, g := range tagGroups {
+		tags = append(tags, g.Tags...)
+	}
 	res := DetailResponse{
-		ID:           stamps.ID,
-		Name:         stamps.Name,
-		FileID:       stamps.FileID,
-		CreatorID:    stamps.CreatorID,
-		IsUnicode:    stamps.IsUnicode,
-		CreatedAt:    stamps.CreatedAt,
-		UpdatedAt:    stamps.UpdatedAt,
-		CountMonthly: stamps.CountMonthly,
-		CountTotal:   stamps.CountTotal,
-		Descriptions: descriptions,
-		Tags:         tags,
+		ID:                        stamps.ID,
+		Name:                      stamps.Name,
+		FileID:                    stamps.FileID,
+		CreatorID:                 stamps.CreatorID,
+		IsUnicode:                 stamps.IsUnicode,
+		CreatedAt:                 stamps.CreatedAt,
+		UpdatedAt:                 stamps.UpdatedAt,
+		CountMonthly:              stamps.CountMonthly,
+		CountTotal:                stamps.CountTotal,
+		Descriptions:              descriptions,
+		DescriptionLang:           lang,
+		AvailableDescriptionLangs: availableLangs,
+		Tags:                      tags,
+		TagGroups:                 tagGroups,
+		MentionedIn:               mentionedIn,
+		Examples:                  examples,
+	}
+	if len(descriptions) > 0 {
+		res.TopDescription = descriptions[0]
+	}
+
+	if lang != nil {
+		c.Response().Header().Set("Content-Language", *lang)
 	}
 
 	return c.JSON(http.StatusOK, res)
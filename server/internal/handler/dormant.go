@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultDormantDays はこの日数使われていないスタンプを休眠中とみなす
+	defaultDormantDays  = 365
+	defaultDormantLimit = 100
+	maxDormantLimit     = 1000
+)
+
+type dormantParams struct {
+	SinceDays *int `query:"since_days"`
+	Limit     *int `query:"limit"`
+	Offset    *int `query:"offset"`
+}
+
+type dormantStampResponse struct {
+	StampID       uuid.UUID  `json:"stamp_id"`
+	StampName     string     `json:"stamp_name"`
+	FileID        uuid.UUID  `json:"file_id"`
+	CreatorID     uuid.UUID  `json:"creator_id"`
+	CreatorTraqID string     `json:"creator_traq_id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+}
+
+func (h *Handler) getDormantStamps(c echo.Context) error {
+	var params dormantParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+	sinceDays := defaultDormantDays
+	if params.SinceDays != nil {
+		if *params.SinceDays < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "since_days must be positive")
+		}
+		sinceDays = *params.SinceDays
+	}
+	limit := defaultDormantLimit
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxDormantLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 1000")
+		}
+		limit = *params.Limit
+	}
+	offset := 0
+	if params.Offset != nil {
+		if *params.Offset < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "offset must not be negative")
+		}
+		offset = *params.Offset
+	}
+
+	stamps, err := h.repo.GetDormantStamps(c.Request().Context(), dormantSince(sinceDays), limit, offset)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	res := make([]dormantStampResponse, len(stamps))
+	for i, s := range stamps {
+		creatorTraqID, _ := h.userCache.GetTraqID(s.CreatorID)
+		res[i] = dormantStampResponse{
+			StampID:       s.ID,
+			StampName:     s.Name,
+			FileID:        s.FileID,
+			CreatorID:     s.CreatorID,
+			CreatorTraqID: creatorTraqID,
+			CreatedAt:     s.CreatedAt,
+			LastUsedAt:    s.LastUsedAt,
+		}
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// dormantSince は days 日前の日付 (この日以降に使われていなければ休眠中) を返す
+func dormantSince(days int) time.Time {
+	now := time.Now()
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -days)
+}
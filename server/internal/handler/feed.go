@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/pkg/config"
+)
+
+const (
+	feedEntryLimit    = 50
+	feedTokenBytes    = 32
+	atomContentType   = "application/atom+xml; charset=utf-8"
+	atomNamespace     = "http://www.w3.org/2005/Atom"
+	feedIDPrefix      = "tag:stampedia.trap.show,2025:"
+	feedTokenQueryKey = "token"
+)
+
+type (
+	atomFeed struct {
+		XMLName xml.Name    `xml:"feed"`
+		Xmlns   string      `xml:"xmlns,attr"`
+		ID      string      `xml:"id"`
+		Title   string      `xml:"title"`
+		Updated string      `xml:"updated"`
+		Link    []atomLink  `xml:"link"`
+		Author  atomAuthor  `xml:"author"`
+		Entries []atomEntry `xml:"entry"`
+	}
+
+	atomEntry struct {
+		ID        string      `xml:"id"`
+		Title     string      `xml:"title"`
+		Updated   string      `xml:"updated"`
+		Published string      `xml:"published,omitempty"`
+		Link      atomLink    `xml:"link"`
+		Author    *atomAuthor `xml:"author,omitempty"`
+		Content   *atomText   `xml:"content,omitempty"`
+	}
+
+	atomLink struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr,omitempty"`
+	}
+
+	atomAuthor struct {
+		Name string `xml:"name"`
+	}
+
+	atomText struct {
+		Type string `xml:"type,attr"`
+		Body string `xml:",chardata"`
+	}
+
+	feedParams struct {
+		Tag []string `query:"tag"`
+	}
+
+	feedTokenResponse struct {
+		Token                string `json:"token"`
+		StampsFeedPath       string `json:"stamps_feed_path"`
+		DescriptionsFeedPath string `json:"descriptions_feed_path"`
+	}
+)
+
+// hashFeedToken はDBに保存・照合するためのフィードトークンのハッシュを返す
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// issueFeedToken はログインユーザーのフィードトークンを発行し直す (以前のトークンは無効になる)
+func (h *Handler) issueFeedToken(c echo.Context) error {
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+
+	buf := make([]byte, feedTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := h.repo.SaveFeedToken(c.Request().Context(), userID, hashFeedToken(token)); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	query := url.Values{feedTokenQueryKey: {token}}.Encode()
+
+	return c.JSON(http.StatusCreated, feedTokenResponse{
+		Token:                token,
+		StampsFeedPath:       "/feeds/stamps.atom?" + query,
+		DescriptionsFeedPath: "/feeds/descriptions.atom?" + query,
+	})
+}
+
+func (h *Handler) revokeFeedToken(c echo.Context) error {
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	if err := h.repo.DeleteFeedToken(c.Request().Context(), userID); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) getStampsFeed(c echo.Context) error {
+	var params feedParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+
+	stamps, err := h.repo.GetNewStamps(c.Request().Context(), params.Tag, feedEntryLimit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	publicURL := config.PublicURL()
+	feed := atomFeed{
+		Xmlns:   atomNamespace,
+		ID:      feedIDPrefix + "stamps",
+		Title:   "Stampedia: 新しく追加されたスタンプ",
+		Updated: formatAtomTime(time.Now()),
+		Link:    []atomLink{{Href: publicURL}},
+		Author:  atomAuthor{Name: "Stampedia"},
+		Entries: make([]atomEntry, len(stamps)),
+	}
+	if len(stamps) > 0 {
+		feed.Updated = formatAtomTime(stamps[0].CreatedAt)
+	}
+	for i, s := range stamps {
+		feed.Entries[i] = atomEntry{
+			ID:        feedIDPrefix + "stamps/" + s.ID.String(),
+			Title:     ":" + s.Name + ":",
+			Updated:   formatAtomTime(s.CreatedAt),
+			Published: formatAtomTime(s.CreatedAt),
+			Link:      atomLink{Href: stampPageURL(publicURL, s.Name)},
+			Author:    h.atomAuthor(s.CreatorID),
+		}
+	}
+
+	return writeAtom(c, feed)
+}
+
+func (h *Handler) getDescriptionsFeed(c echo.Context) error {
+	var params feedParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+
+	descriptions, err := h.repo.GetRecentDescriptions(c.Request().Context(), params.Tag, feedEntryLimit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	publicURL := config.PublicURL()
+	feed := atomFeed{
+		Xmlns:   atomNamespace,
+		ID:      feedIDPrefix + "descriptions",
+		Title:   "Stampedia: 最近書かれた説明文",
+		Updated: formatAtomTime(time.Now()),
+		Link:    []atomLink{{Href: publicURL}},
+		Author:  atomAuthor{Name: "Stampedia"},
+		Entries: make([]atomEntry, len(descriptions)),
+	}
+	if len(descriptions) > 0 {
+		feed.Updated = formatAtomTime(descriptions[0].UpdatedAt)
+	}
+	for i, d := range descriptions {
+		feed.Entries[i] = atomEntry{
+			// 同じ説明文が更新された場合も別のエントリとして配信されるよう、更新日時をIDに含める
+			ID:        fmt.Sprintf("%sdescriptions/%s/%s/%d", feedIDPrefix, d.StampID, d.CreatorID, d.UpdatedAt.Unix()),
+			Title:     ":" + d.StampName + ": の説明文",
+			Updated:   formatAtomTime(d.UpdatedAt),
+			Published: formatAtomTime(d.CreatedAt),
+			Link:      atomLink{Href: stampPageURL(publicURL, d.StampName)},
+			Author:    h.atomAuthor(d.CreatorID),
+			Content:   &atomText{Type: "text", Body: d.Description},
+		}
+	}
+
+	return writeAtom(c, feed)
+}
+
+func (h *Handler) atomAuthor(userID uuid.UUID) *atomAuthor {
+	traqID, ok := h.userCache.GetTraqID(userID)
+	if !ok {
+		return nil
+	}
+
+	return &atomAuthor{Name: traqID}
+}
+
+func stampPageURL(publicURL string, stampName string) string {
+	return publicURL + "/stamp/" + url.PathEscape(stampName)
+}
+
+func formatAtomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func writeAtom(c echo.Context, feed atomFeed) error {
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.Blob(http.StatusOK, atomContentType, append([]byte(xml.Header), body...))
+}
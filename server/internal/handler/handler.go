@@ -2,18 +2,25 @@ package handler
 
 import (
 	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/contentpolicy"
+	"github.com/traP-jp/1m25_11/server/internal/descgen"
 	"github.com/traP-jp/1m25_11/server/internal/repository"
 )
 
 type Handler struct {
 	repo      *repository.Repository
 	userCache *UserCache
+	policy    *contentpolicy.Policy
+	// generator は説明文の生成器 (nil なら生成しない)
+	generator descgen.Generator
 }
 
-func New(repo *repository.Repository, userCache *UserCache) *Handler {
+func New(repo *repository.Repository, userCache *UserCache, policy *contentpolicy.Policy, generator descgen.Generator) *Handler {
 	return &Handler{
 		repo:      repo,
 		userCache: userCache,
+		policy:    policy,
+		generator: generator,
 	}
 }
 
@@ -23,6 +30,11 @@ func (h *Handler) SetupRoutes(api *echo.Group) {
 	bulkAPI.POST("/tags", h.BulkCreateTags)
 	bulkAPI.POST("/stamps-meta", h.BulkAddStampMeta)
 
+	feedAPI := api.Group("/feeds")
+	feedAPI.Use(h.FeedTokenMiddleware)
+	feedAPI.GET("/stamps.atom", h.getStampsFeed)
+	feedAPI.GET("/descriptions.atom", h.getDescriptionsFeed)
+
 	protected := api.Group("")
 	protected.Use(h.ProxySecretMiddleware)
 	protected.Use(h.AuthMiddleware)
@@ -30,23 +42,63 @@ func (h *Handler) SetupRoutes(api *echo.Group) {
 	stampAPI := protected.Group("/stamps")
 	stampAPI.GET("/search", h.SearchStamps)
 	stampAPI.GET("/ranking", h.getRanking)
+	stampAPI.GET("/trending", h.getTrending)
+	stampAPI.GET("/dormant", h.getDormantStamps)
 	stampAPI.GET("", h.getStamps)
 	stampAPI.GET("/:stampId", h.getDetails)
 	stampAPI.POST("/:stampId/tags/:tagId", h.createStampTags)
 	stampAPI.DELETE("/:stampId/tags/:tagId", h.deleteStampTags)
+	stampAPI.GET("/:stampId/tag-suggestions", h.getTagSuggestions)
 	stampAPI.GET("/:stampId/descriptions", h.getDescriptions)
 	stampAPI.POST("/:stampId/descriptions", h.createDescriptions)
 	stampAPI.PUT("/:stampId/descriptions", h.updateDescriptions)
 	stampAPI.DELETE("/:stampId/descriptions", h.deleteDescriptions)
+	stampAPI.GET("/:stampId/descriptions/history", h.getDescriptionHistory)
+	stampAPI.POST("/:stampId/descriptions/rollback", h.rollbackDescription)
+	stampAPI.PUT("/:stampId/descriptions/:creatorId/vote", h.voteDescription)
+	stampAPI.DELETE("/:stampId/descriptions/:creatorId/vote", h.deleteDescriptionVote)
+	stampAPI.POST("/:stampId/examples", h.createStampExample)
+	stampAPI.DELETE("/:stampId/examples/:messageId", h.deleteStampExample)
 
 	tagAPI := protected.Group("/tags")
 	tagAPI.GET("", h.getTags)
 	tagAPI.POST("", h.createTags)
+	tagAPI.GET("/lookup", h.lookupTag)
+	tagAPI.GET("/graph", h.getTagGraph)
+	tagAPI.POST("/batch-operations/:operationId/undo", h.undoTagBatchOperation)
+	tagAPI.GET("/proposals", h.getTagProposals)
+	tagAPI.POST("/:tagId/approve", h.approveTag, h.AdminMiddleware)
+	tagAPI.POST("/:tagId/reject", h.rejectTag, h.AdminMiddleware)
 	tagAPI.GET("/:tagId", h.getTagDetails)
 	tagAPI.PUT("/:tagId", h.updateTags)
 	tagAPI.DELETE("/:tagId", h.deleteTags)
+	tagAPI.PUT("/:tagId/parent", h.updateTagParent)
+	tagAPI.PUT("/:tagId/wiki", h.updateTagWiki)
+	tagAPI.PUT("/:tagId/category", h.updateTagCategoryOfTag, h.AdminMiddleware)
 	tagAPI.GET("/:tagId/stamps", h.getStampsByTag)
+	tagAPI.POST("/:tagId/stamps\\:batch", h.batchUpdateStampTags)
+	tagAPI.GET("/:tagId/cooccurring", h.getCooccurringTags)
+	tagAPI.POST("/:tagId/merge-into/:targetId", h.mergeTags)
+
+	tagCategoryAPI := protected.Group("/tag-categories")
+	tagCategoryAPI.GET("", h.getTagCategories)
+	tagCategoryAPI.POST("", h.createTagCategory, h.AdminMiddleware)
+	tagCategoryAPI.PUT("/:categoryId", h.updateTagCategory, h.AdminMiddleware)
+	tagCategoryAPI.DELETE("/:categoryId", h.deleteTagCategory, h.AdminMiddleware)
+
+	reportAPI := protected.Group("/reports")
+	reportAPI.POST("", h.createReport)
+	reportAPI.GET("", h.getReports, h.AdminMiddleware)
+	reportAPI.POST("/:reportId/resolve", h.resolveReport, h.AdminMiddleware)
+
+	leaderboardAPI := protected.Group("/leaderboards")
+	leaderboardAPI.GET("/creators", h.getCreatorLeaderboard)
+	leaderboardAPI.GET("/contributors", h.getContributorLeaderboard)
 
 	protected.GET("/me", h.GetUser)
+	protected.POST("/me/feed-token", h.issueFeedToken)
+	protected.DELETE("/me/feed-token", h.revokeFeedToken)
+	protected.GET("/me/notifications", h.getNotifications)
+	protected.POST("/me/notifications/:notificationId/read", h.markNotificationRead)
 	protected.GET("/users-list", h.getUsersList)
 }
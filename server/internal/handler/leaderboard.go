@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+const (
+	defaultLeaderboardLimit = 50
+	maxLeaderboardLimit     = 500
+)
+
+type leaderboardParams struct {
+	Period *string `query:"period"`
+	Sort   *string `query:"sort"`
+	Limit  *int    `query:"limit"`
+}
+
+type creatorLeaderboardEntry struct {
+	Rank       int       `json:"rank"`
+	UserID     uuid.UUID `json:"user_id"`
+	TraqID     string    `json:"traq_id"`
+	StampCount int       `json:"stamp_count"`
+	TotalUsage int64     `json:"total_usage"`
+}
+
+type contributorLeaderboardEntry struct {
+	Rank             int       `json:"rank"`
+	UserID           uuid.UUID `json:"user_id"`
+	TraqID           string    `json:"traq_id"`
+	TagCount         int       `json:"tag_count"`
+	StampTagCount    int       `json:"stamp_tag_count"`
+	DescriptionCount int       `json:"description_count"`
+	Total            int       `json:"total"`
+}
+
+func (p leaderboardParams) limit() (int, error) {
+	if p.Limit == nil {
+		return defaultLeaderboardLimit, nil
+	}
+	if *p.Limit < 1 || *p.Limit > maxLeaderboardLimit {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 500")
+	}
+
+	return *p.Limit, nil
+}
+
+func (h *Handler) getCreatorLeaderboard(c echo.Context) error {
+	var params leaderboardParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+	limit, err := params.limit()
+	if err != nil {
+		return err
+	}
+	sortBy := "stamps"
+	if params.Sort != nil {
+		sortBy = *params.Sort
+	}
+	var key func(repository.CreatorStats) int64
+	switch sortBy {
+	case "stamps":
+		key = func(s repository.CreatorStats) int64 { return int64(s.StampCount) }
+	case "usage":
+		key = func(s repository.CreatorStats) int64 { return s.TotalUsage }
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "sort must be stamps or usage")
+	}
+
+	stats, err := h.repo.GetCreatorStats(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	// traQ のユーザー (bot を除く) として解決できない作成者は除外する
+	entries := make([]creatorLeaderboardEntry, 0, len(stats))
+	keys := make([]int64, 0, len(stats))
+	sort.SliceStable(stats, func(i, j int) bool {
+		if ki, kj := key(stats[i]), key(stats[j]); ki != kj {
+			return ki > kj
+		}
+
+		return h.leaderboardTieLess(stats[i].UserID, stats[j].UserID)
+	})
+	for _, s := range stats {
+		traqID, ok := h.userCache.GetTraqID(s.UserID)
+		if !ok {
+			continue
+		}
+		entries = append(entries, creatorLeaderboardEntry{
+			UserID:     s.UserID,
+			TraqID:     traqID,
+			StampCount: s.StampCount,
+			TotalUsage: s.TotalUsage,
+		})
+		keys = append(keys, key(s))
+	}
+	for i, rank := range competitionRanks(keys) {
+		entries[i].Rank = rank
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+func (h *Handler) getContributorLeaderboard(c echo.Context) error {
+	var params leaderboardParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+	limit, err := params.limit()
+	if err != nil {
+		return err
+	}
+	period := repository.RankingPeriodMonth
+	if params.Period != nil {
+		period, err = repository.ParseRankingPeriod(*params.Period)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "period must be one of day, week, month, year, all").SetInternal(err)
+		}
+	}
+	sortBy := "total"
+	if params.Sort != nil {
+		sortBy = *params.Sort
+	}
+	var key func(repository.ContributorStats) int64
+	switch sortBy {
+	case "total":
+		key = func(s repository.ContributorStats) int64 { return int64(s.Total) }
+	case "tags":
+		key = func(s repository.ContributorStats) int64 { return int64(s.TagCount) }
+	case "stamp_tags":
+		key = func(s repository.ContributorStats) int64 { return int64(s.StampTagCount) }
+	case "descriptions":
+		key = func(s repository.ContributorStats) int64 { return int64(s.DescriptionCount) }
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "sort must be one of total, tags, stamp_tags, descriptions")
+	}
+
+	stats, err := h.repo.GetContributorStats(c.Request().Context(), period.Start(time.Now()))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	entries := make([]contributorLeaderboardEntry, 0, len(stats))
+	keys := make([]int64, 0, len(stats))
+	sort.SliceStable(stats, func(i, j int) bool {
+		if ki, kj := key(stats[i]), key(stats[j]); ki != kj {
+			return ki > kj
+		}
+
+		return h.leaderboardTieLess(stats[i].UserID, stats[j].UserID)
+	})
+	for _, s := range stats {
+		if key(s) == 0 {
+			continue
+		}
+		traqID, ok := h.userCache.GetTraqID(s.UserID)
+		if !ok {
+			continue
+		}
+		entries = append(entries, contributorLeaderboardEntry{
+			UserID:           s.UserID,
+			TraqID:           traqID,
+			TagCount:         s.TagCount,
+			StampTagCount:    s.StampTagCount,
+			DescriptionCount: s.DescriptionCount,
+			Total:            s.Total,
+		})
+		keys = append(keys, key(s))
+	}
+	for i, rank := range competitionRanks(keys) {
+		entries[i].Rank = rank
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+// leaderboardTieLess は同じ値のユーザーを traQ ID の昇順 (解決できなければ UUID の順) に並べる。同順位の並びをリクエストごとに変えないため
+func (h *Handler) leaderboardTieLess(a uuid.UUID, b uuid.UUID) bool {
+	traqA, _ := h.userCache.GetTraqID(a)
+	traqB, _ := h.userCache.GetTraqID(b)
+	if traqA != traqB {
+		return traqA < traqB
+	}
+
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+// competitionRanks は降順に並んだ値に順位を付ける (同じ値は同順位)
+func competitionRanks(sortedDesc []int64) []int {
+	ranks := make([]int, len(sortedDesc))
+	for i, v := range sortedDesc {
+		if i > 0 && v == sortedDesc[i-1] {
+			ranks[i] = ranks[i-1]
+
+			continue
+		}
+		ranks[i] = i + 1
+	}
+
+	return ranks
+}
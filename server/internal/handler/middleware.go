@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
 	"github.com/traP-jp/1m25_11/server/pkg/config"
 )
 
@@ -24,6 +28,31 @@ func (h *Handler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// AdminMiddleware は AuthMiddleware で認証したユーザーが管理者でなければ 403 を返す
+func (h *Handler) AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		id, ok := c.Get(userIDContextKey).(uuid.UUID)
+		if !ok {
+			return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+		}
+		if !h.isAdmin(id) {
+			return echo.NewHTTPError(http.StatusForbidden, "forbidden")
+		}
+
+		return next(c)
+	}
+}
+
+// isAdmin はユーザーが ADMIN_USERS に含まれているかを返す
+func (h *Handler) isAdmin(userID uuid.UUID) bool {
+	traqID, ok := h.userCache.GetTraqID(userID)
+	if !ok {
+		return false
+	}
+
+	return slices.Contains(config.AdminUsers(), strings.ToLower(traqID))
+}
+
 func (h *Handler) ProxySecretMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		secret := os.Getenv("PROXY_SECRET")
@@ -59,3 +88,26 @@ func (h *Handler) BulkAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		return next(c)
 	}
 }
+
+// FeedTokenMiddleware はフィードリーダー向けに、クエリパラメータのフィードトークンでユーザーを認証する
+func (h *Handler) FeedTokenMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := c.QueryParam(feedTokenQueryKey)
+		if token == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+		}
+
+		id, err := h.repo.GetUserIDByFeedToken(c.Request().Context(), hashFeedToken(token))
+		if err != nil {
+			if errors.Is(err, repository.ErrFeedTokenNotFound) {
+				return echo.NewHTTPError(http.StatusUnauthorized, "unauthorized")
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+		}
+
+		c.Set(userIDContextKey, id)
+
+		return next(c)
+	}
+}
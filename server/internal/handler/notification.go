@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+type getNotificationsParams struct {
+	Unread bool `query:"unread"`
+	Limit  *int `query:"limit"`
+}
+
+func (h *Handler) getNotifications(c echo.Context) error {
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	var params getNotificationsParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+	limit := defaultNotificationLimit
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxNotificationLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 200")
+		}
+		limit = *params.Limit
+	}
+
+	notifications, err := h.repo.GetNotifications(c.Request().Context(), userID, params.Unread, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, notifications)
+}
+
+func (h *Handler) markNotificationRead(c echo.Context) error {
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	notificationID, err := uuid.Parse(c.Param("notificationId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid notification ID").SetInternal(err)
+	}
+
+	if err := h.repo.MarkNotificationRead(c.Request().Context(), userID, notificationID); err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "notification not found")
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
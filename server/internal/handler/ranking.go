@@ -2,20 +2,83 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
 )
 
+const (
+	defaultRankingLimit = 100
+	maxRankingLimit     = 1000
+)
+
+type rankingParams struct {
+	Period  *string `query:"period"`
+	Order   *string `query:"order"`
+	Limit   *int    `query:"limit"`
+	Unicode *bool   `query:"unicode"`
+	Tag     *string `query:"tag"`
+	Creator *string `query:"creator"`
+}
+
 type rankingResponse struct {
-	StampID      uuid.UUID `json:"stamp_id"`
-	MonthlyCount int       `json:"month_count"`
-	TotalCount   int       `json:"total_count"`
+	Rank          int       `json:"rank"`
+	PreviousRank  *int      `json:"previous_rank"`
+	RankChange    int       `json:"rank_change"`
+	Movement      string    `json:"movement"`
+	StampID       uuid.UUID `json:"stamp_id"`
+	StampName     string    `json:"stamp_name"`
+	FileID        uuid.UUID `json:"file_id"`
+	Count         int64     `json:"count"`
+	PreviousCount int64     `json:"previous_count"`
+	ComputedAt    time.Time `json:"computed_at"`
 }
 
 func (h *Handler) getRanking(c echo.Context) error {
+	var params rankingParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
 
-	rankingResults, err := h.repo.GetRanking(c.Request().Context())
+	repoParams := repository.GetRankingParams{
+		Period:    repository.RankingPeriodMonth,
+		Order:     "asc",
+		Limit:     defaultRankingLimit,
+		IsUnicode: params.Unicode,
+	}
+	if params.Period != nil {
+		period, err := repository.ParseRankingPeriod(*params.Period)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "period must be one of day, week, month, year, all").SetInternal(err)
+		}
+		repoParams.Period = period
+	}
+	if params.Order != nil {
+		if *params.Order != "asc" && *params.Order != "desc" {
+			return echo.NewHTTPError(http.StatusBadRequest, "order must be asc or desc")
+		}
+		repoParams.Order = *params.Order
+	}
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxRankingLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 1000")
+		}
+		repoParams.Limit = *params.Limit
+	}
+	if params.Tag != nil {
+		repoParams.TagName = *params.Tag
+	}
+	if params.Creator != nil && *params.Creator != "" {
+		creatorID, err := h.resolveUserID(*params.Creator)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "unknown creator").SetInternal(err)
+		}
+		repoParams.CreatorID = &creatorID
+	}
+
+	rankingResults, err := h.repo.GetRanking(c.Request().Context(), repoParams)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 	}
@@ -23,9 +86,26 @@ func (h *Handler) getRanking(c echo.Context) error {
 	res := make([]rankingResponse, len(rankingResults))
 	for i, r := range rankingResults {
 		res[i] = rankingResponse{
-			StampID:      r.StampID,
-			MonthlyCount: r.MonthlyCount,
-			TotalCount:   r.TotalCount,
+			Rank:          r.Rank,
+			PreviousRank:  r.PreviousRank,
+			Movement:      "new",
+			StampID:       r.StampID,
+			StampName:     r.StampName,
+			FileID:        r.FileID,
+			Count:         r.Count,
+			PreviousCount: r.PreviousCount,
+			ComputedAt:    r.ComputedAt,
+		}
+		if r.PreviousRank != nil {
+			res[i].RankChange = *r.PreviousRank - r.Rank
+			switch {
+			case res[i].RankChange > 0:
+				res[i].Movement = "up"
+			case res[i].RankChange < 0:
+				res[i].Movement = "down"
+			default:
+				res[i].Movement = "same"
+			}
 		}
 	}
 
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+const (
+	reportReasonMaxLength = 1000
+	defaultReportLimit    = 50
+	maxReportLimit        = 200
+)
+
+type (
+	PostReportsJSONRequestBody struct {
+		TargetType string     `json:"target_type"`
+		StampID    *uuid.UUID `json:"stamp_id"`
+		CreatorID  *uuid.UUID `json:"creator_id"`
+		Lang       *string    `json:"lang"`
+		TagID      *uuid.UUID `json:"tag_id"`
+		Reason     string     `json:"reason"`
+	}
+
+	PostReportsReportIdResolveJSONRequestBody struct {
+		Action string `json:"action"`
+	}
+
+	getReportsParams struct {
+		Status *string `query:"status"`
+		Limit  *int    `query:"limit"`
+	}
+)
+
+// reportTarget は通報の対象の種類に必要なIDだけが指定されているかを確かめて対象を返す
+func (b PostReportsJSONRequestBody) reportTarget() (repository.ReportTarget, error) {
+	target := repository.ReportTarget{Type: b.TargetType}
+	switch b.TargetType {
+	case repository.ReportTargetDescription:
+		if b.StampID == nil || b.CreatorID == nil || b.TagID != nil {
+			return target, errors.New("description reports require stamp_id and creator_id")
+		}
+		raw := ""
+		if b.Lang != nil {
+			raw = *b.Lang
+		}
+		lang, err := normalizeDescriptionLang(raw)
+		if err != nil {
+			return target, err
+		}
+		target.StampID, target.CreatorID, target.Lang = b.StampID, b.CreatorID, &lang
+	case repository.ReportTargetTag:
+		if b.TagID == nil || b.StampID != nil || b.CreatorID != nil || b.Lang != nil {
+			return target, errors.New("tag reports require only tag_id")
+		}
+		target.TagID = b.TagID
+	case repository.ReportTargetStampTag:
+		if b.StampID == nil || b.TagID == nil || b.CreatorID != nil || b.Lang != nil {
+			return target, errors.New("stamp_tag reports require stamp_id and tag_id")
+		}
+		target.StampID, target.TagID = b.StampID, b.TagID
+	default:
+		return target, errors.New("target_type must be one of description, tag, stamp_tag")
+	}
+
+	return target, nil
+}
+
+// createReport は説明文・タグ・タグ付けを通報する
+func (h *Handler) createReport(c echo.Context) error {
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	var body PostReportsJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body").SetInternal(err)
+	}
+	target, err := body.reportTarget()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	reason := strings.TrimSpace(body.Reason)
+	if reason == "" || utf8.RuneCountInString(reason) > reportReasonMaxLength {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("reason must be 1 to %d characters", reportReasonMaxLength))
+	}
+
+	report, err := h.repo.CreateReport(c.Request().Context(), target, reason, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrReportTargetNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "report target not found").SetInternal(err)
+		}
+		if errors.Is(err, repository.ErrReportAlreadyExists) {
+			return echo.NewHTTPError(http.StatusConflict, "you have already reported this content").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, report)
+}
+
+// getReports は管理者向けの通報の一覧 (既定では未対応のもの)
+func (h *Handler) getReports(c echo.Context) error {
+	var params getReportsParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+	status := repository.ReportStatusOpen
+	if params.Status != nil {
+		status = *params.Status
+	}
+	if status != repository.ReportStatusOpen && status != repository.ReportStatusResolved {
+		return echo.NewHTTPError(http.StatusBadRequest, "status must be open or resolved")
+	}
+	limit := defaultReportLimit
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxReportLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxReportLimit))
+		}
+		limit = *params.Limit
+	}
+
+	reports, err := h.repo.GetReports(c.Request().Context(), status, limit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, reports)
+}
+
+// resolveReport は通報に対応する。対象を非表示 (hide) か削除 (delete) にするか、何もせずに却下 (dismiss) する
+func (h *Handler) resolveReport(c echo.Context) error {
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	reportID, err := uuid.Parse(c.Param("reportId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid report ID").SetInternal(err)
+	}
+	var body PostReportsReportIdResolveJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body").SetInternal(err)
+	}
+	switch body.Action {
+	case repository.ReportActionHide, repository.ReportActionDelete, repository.ReportActionDismiss:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "action must be one of hide, delete, dismiss")
+	}
+
+	if err := h.repo.ResolveReport(c.Request().Context(), reportID, body.Action, userID); err != nil {
+		if errors.Is(err, repository.ErrReportNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "report not found").SetInternal(err)
+		}
+		if errors.Is(err, repository.ErrReportAlreadyResolved) {
+			return echo.NewHTTPError(http.StatusConflict, "report is already resolved").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
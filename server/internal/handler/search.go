@@ -8,8 +8,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/traP-jp/1m25_11/server/internal/repository"
+	"github.com/traP-jp/1m25_11/server/internal/textanalysis"
 )
 
 type searchStampsParams struct {
@@ -26,6 +28,11 @@ type searchStampsParams struct {
 	CountMonthlyMin    *int     `query:"count_monthly_min"`
 	CountMonthlyMax    *int     `query:"count_monthly_max"`
 	SortBy             *string  `query:"sortby"`
+	IncludeDescendants *bool    `query:"include_descendants"`
+	CategoryID         *string  `query:"category_id"`
+	ExcludeDormant     *bool    `query:"exclude_dormant"`
+	DormantDays        *int     `query:"dormant_days"`
+	Lang               *string  `query:"lang"`
 }
 
 type searchResultResponse struct {
@@ -97,6 +104,32 @@ func (h *Handler) SearchStamps(c echo.Context) error {
 	if params.SortBy != nil {
 		repoParams.SortBy = *params.SortBy
 	}
+	if params.CategoryID != nil && *params.CategoryID != "" {
+		categoryID, err := uuid.Parse(*params.CategoryID)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid request parameters: invalid category_id")
+		}
+		repoParams.CategoryID = &categoryID
+	}
+	if params.IncludeDescendants != nil {
+		repoParams.IncludeDescendants = *params.IncludeDescendants
+	}
+	if params.ExcludeDormant != nil && *params.ExcludeDormant {
+		dormantDays := defaultDormantDays
+		if params.DormantDays != nil && *params.DormantDays > 0 {
+			dormantDays = *params.DormantDays
+		}
+		activeSince := dormantSince(dormantDays)
+		repoParams.ActiveSince = &activeSince
+	}
+
+	if params.Lang != nil && *params.Lang != "" {
+		lang, err := normalizeDescriptionLang(*params.Lang)
+		if err != nil {
+			return c.String(http.StatusBadRequest, "Invalid request parameters: "+err.Error())
+		}
+		repoParams.Lang = lang
+	}
 
 	foundStamps, err := h.repo.SearchStamps(c.Request().Context(), repoParams)
 	if err != nil {
@@ -146,6 +179,38 @@ func (h *Handler) SearchStamps(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// descriptionFieldWeights は関連度の計算での説明文の項目ごとの重み。意味は本文と同じだけ重く、由来や例文は軽く扱う。
+// text は英語以外の説明文、enText は英語の説明文の項目
+var descriptionFieldWeights = []struct {
+	text   func(repository.StampForSearch) string
+	enText func(repository.StampForSearch) string
+	weight float64
+}{
+	{func(s repository.StampForSearch) string { return s.Descriptions }, func(s repository.StampForSearch) string { return s.EnDescriptions }, 1.0},
+	{func(s repository.StampForSearch) string { return s.Meanings }, func(s repository.StampForSearch) string { return s.EnMeanings }, 1.0},
+	{func(s repository.StampForSearch) string { return s.Usages }, func(s repository.StampForSearch) string { return s.EnUsages }, 0.6},
+	{func(s repository.StampForSearch) string { return s.Examples }, func(s repository.StampForSearch) string { return s.EnExamples }, 0.4},
+	{func(s repository.StampForSearch) string { return s.Origins }, func(s repository.StampForSearch) string { return s.EnOrigins }, 0.3},
+}
+
+// occurrenceScore は出現回数に応じて 0 から 1 に近づくスコアを返す
+func occurrenceScore(count int) float64 {
+	return 1.0 - math.Exp(float64(-count))
+}
+
+// descriptionTermScore は説明文の各項目での term のスコアに重みを掛け、そのうち最大のものを返す。
+// 日本語などは部分文字列として、英語は語幹の一致する単語として数える
+func descriptionTermScore(term string, stamp repository.StampForSearch) float64 {
+	best := 0.0
+	for _, f := range descriptionFieldWeights {
+		best = max(best,
+			f.weight*occurrenceScore(strings.Count(strings.ToLower(f.text(stamp)), strings.ToLower(term))),
+			f.weight*occurrenceScore(textanalysis.EnglishOccurrences(term, f.enText(stamp))))
+	}
+
+	return best
+}
+
 func calculateRelativityScore(stamp repository.StampForSearch, params repository.SearchStampsParams) float64 {
 	divisor := 0.0
 	totalScore := 0.0
@@ -169,8 +234,14 @@ func calculateRelativityScore(stamp repository.StampForSearch, params repository
 		divisor++
 	}
 	if params.Description != "" {
-		scoreDescription := calculateSubScore(params.Description, stamp.Descriptions)
-		totalScore += scoreDescription
+		terms := strings.Fields(params.Description)
+		var sumOfX float64
+		for _, term := range terms {
+			sumOfX += descriptionTermScore(term, stamp)
+		}
+		if len(terms) > 0 {
+			totalScore += sumOfX / float64(len(terms))
+		}
 		divisor++
 	}
 	if len(params.Tags) > 0 {
@@ -185,7 +256,7 @@ func calculateRelativityScore(stamp repository.StampForSearch, params repository
 		for _, term := range qTerms {
 			xName := 1.0 - math.Exp(float64(-strings.Count(strings.ToLower(stamp.Name), strings.ToLower(term))))
 			xTag := 1.0 - math.Exp(float64(-strings.Count(strings.ToLower(stamp.Tags), strings.ToLower(term))))
-			xDesc := 1.0 - math.Exp(float64(-strings.Count(strings.ToLower(stamp.Descriptions), strings.ToLower(term))))
+			xDesc := descriptionTermScore(term, stamp)
 			xi := (xName + xTag + xDesc) / 3.0
 			sumOfXi += xi
 		}
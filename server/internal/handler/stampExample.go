@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+type PostStampsStampIdExamplesJSONRequestBody struct {
+	MessageURL string `json:"message_url"`
+}
+
+// createStampExample は traQ のメッセージをスタンプの使用例として登録する。
+// メッセージはスタンプを本文で使っているかリアクションされている公開チャンネルのものに限る
+func (h *Handler) createStampExample(c echo.Context) error {
+	stampID, err := uuid.Parse(c.Param("stampId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	var body PostStampsStampIdExamplesJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	messageID, ok := parseTraqMessageURL(body.MessageURL)
+	if !ok {
+		return echo.NewHTTPError(http.StatusBadRequest, "message_url must be a traQ message URL (https://q.trap.jp/messages/{id})")
+	}
+	botToken := os.Getenv("BOT_TOKEN_KEY")
+	if botToken == "" {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "traQ API is not configured")
+	}
+
+	ctx := c.Request().Context()
+	stamp, err := h.repo.GetStampByStampID(ctx, stampID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "stamp not found").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	message, err := fetchTraqMessage(ctx, botToken, messageID)
+	if err != nil {
+		if errors.Is(err, errTraqNotFound) {
+			return echo.NewHTTPError(http.StatusBadRequest, "message not found or not accessible").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusBadGateway, "failed to fetch message from traQ").SetInternal(err)
+	}
+	if !message.usesStamp(stamp.ID, stamp.Name) {
+		return echo.NewHTTPError(http.StatusBadRequest, "message does not contain or react with the stamp")
+	}
+	channelPath, err := fetchTraqChannelPath(ctx, botToken, message.ChannelID)
+	if err != nil {
+		if errors.Is(err, errTraqNotFound) {
+			return echo.NewHTTPError(http.StatusBadRequest, "messages in private channels cannot be used as examples").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusBadGateway, "failed to fetch channel from traQ").SetInternal(err)
+	}
+	authorName, err := h.fetchTraqUserName(ctx, botToken, message.UserID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, "failed to fetch message author from traQ").SetInternal(err)
+	}
+
+	example := &repository.StampMessageExample{
+		StampID:     stampID,
+		MessageID:   message.ID,
+		ChannelID:   message.ChannelID,
+		ChannelPath: channelPath,
+		AuthorID:    message.UserID,
+		AuthorName:  authorName,
+		Content:     message.Content,
+		PostedAt:    message.CreatedAt,
+		CreatorID:   userID,
+		CreatedAt:   time.Now(),
+	}
+	if err := h.repo.CreateStampExample(ctx, example); err != nil {
+		if errors.Is(err, repository.ErrStampExampleAlreadyExists) {
+			return echo.NewHTTPError(http.StatusConflict, "this message is already an example of the stamp").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, example)
+}
+
+// deleteStampExample は使用例を削除する。登録したユーザーか管理者だけが削除できる
+func (h *Handler) deleteStampExample(c echo.Context) error {
+	stampID, err := uuid.Parse(c.Param("stampId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	messageID, err := uuid.Parse(c.Param("messageId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest).SetInternal(err)
+	}
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+
+	example, err := h.repo.GetStampExample(c.Request().Context(), stampID, messageID)
+	if err != nil {
+		if errors.Is(err, repository.ErrStampExampleNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "example not found").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	if example.CreatorID != userID && !h.isAdmin(userID) {
+		return echo.NewHTTPError(http.StatusForbidden, "only the user who added this example or an admin can delete it")
+	}
+	if err := h.repo.DeleteStampExample(c.Request().Context(), stampID, messageID); err != nil {
+		if errors.Is(err, repository.ErrStampExampleNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "example not found").SetInternal(err)
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
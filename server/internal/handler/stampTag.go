@@ -48,6 +48,7 @@ func (h *Handler) createStampTags(c echo.Context) error {
 
 		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 	}
+	h.approveTagIfWidelyUsed(c, tagID)
 
 	return c.NoContent(http.StatusNoContent)
 }
@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/contentpolicy"
 	"github.com/traP-jp/1m25_11/server/internal/repository"
 )
 
@@ -45,8 +47,14 @@ type Tag struct {
 	CreatorId uuid.UUID      `json:"creator_id"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
+	ParentId  *uuid.UUID     `json:"parent_id"`
+	Status    string         `json:"status"`
 	Count     int            `json:"count"`
+	Aliases   []string       `json:"aliases"`
 	Stamps    []StampSummary `json:"stamps"`
+	// Wiki はwikiが一度も編集されていなければ nil
+	Wiki        *TagWiki          `json:"wiki"`
+	WikiHistory []TagWikiRevision `json:"wiki_history"`
 }
 
 type PostTagsJSONRequestBody struct {
@@ -57,15 +65,109 @@ type PutTagsTagIdJSONRequestBody struct {
 	Name string `json:"name"`
 }
 
+type PutTagsTagIdParentJSONRequestBody struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type TagTreeNode struct {
+	Id       uuid.UUID      `json:"tag_id"`
+	Name     string         `json:"tag_name"`
+	Children []*TagTreeNode `json:"children"`
+}
+
+type getTagsParams struct {
+	Tree     bool    `query:"tree"`
+	Sort     *string `query:"sort"`
+	MinCount *int    `query:"min_count"`
+	Prefix   *string `query:"prefix"`
+	Limit    *int    `query:"limit"`
+	Offset   *int    `query:"offset"`
+}
+
+const maxTagsLimit = 1000
+
 func (h *Handler) getTags(c echo.Context) error {
-	tagSummaries, err := h.repo.GetTags(c.Request().Context())
+	var params getTagsParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid query parameters.",
+		})
+	}
+	if params.Tree {
+		return h.getTagTree(c)
+	}
+
+	repoParams := repository.GetTagsParams{}
+	if params.Sort != nil {
+		switch *params.Sort {
+		case "name", "count", "created_at":
+			repoParams.Sort = *params.Sort
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, Error{
+				Message: "sort must be one of name, count, created_at.",
+			})
+		}
+	}
+	if params.MinCount != nil {
+		repoParams.MinCount = *params.MinCount
+	}
+	if params.Prefix != nil {
+		repoParams.Prefix = *params.Prefix
+	}
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxTagsLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, Error{
+				Message: "limit must be between 1 and 1000.",
+			})
+		}
+		repoParams.Limit = *params.Limit
+	}
+	if params.Offset != nil {
+		if *params.Offset < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, Error{
+				Message: "offset must not be negative.",
+			})
+		}
+		repoParams.Offset = *params.Offset
+	}
+
+	tags, total, err := h.repo.GetTags(c.Request().Context(), repoParams)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, Error{
+			Message: fmt.Sprintf("failed to get tags: %s", err.Error()),
+		})
+	}
+
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+func (h *Handler) getTagTree(c echo.Context) error {
+	nodes, err := h.repo.GetTagNodes(c.Request().Context())
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, Error{
 			Message: fmt.Sprintf("failed to get tags: %s", err.Error()),
 		})
 	}
 
-	return c.JSON(http.StatusOK, tagSummaries)
+	treeNodes := make(map[uuid.UUID]*TagTreeNode, len(nodes))
+	for _, n := range nodes {
+		treeNodes[n.ID] = &TagTreeNode{Id: n.ID, Name: n.Name, Children: []*TagTreeNode{}}
+	}
+	roots := []*TagTreeNode{}
+	for _, n := range nodes {
+		if n.ParentID != nil {
+			if parent, ok := treeNodes[*n.ParentID]; ok {
+				parent.Children = append(parent.Children, treeNodes[n.ID])
+
+				continue
+			}
+		}
+		roots = append(roots, treeNodes[n.ID])
+	}
+
+	return c.JSON(http.StatusOK, roots)
 }
 
 func (h *Handler) createTags(c echo.Context) error {
@@ -79,10 +181,36 @@ func (h *Handler) createTags(c echo.Context) error {
 	if !ok {
 		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
 	}
+	name, err := normalizeTagName(body.Name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: err.Error(),
+		})
+	}
+	if v := h.policy.CheckTagName(creatorID, name); v != nil {
+		return c.JSON(http.StatusUnprocessableEntity, v)
+	}
 
-	newTag, err := h.repo.CreateTags(c.Request().Context(), repository.CreateTagParams{
-		Name:      body.Name,
+	ctx := c.Request().Context()
+	entries, err := h.repo.GetTagNameEntries(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, Error{
+			Message: fmt.Sprintf("failed to create tag: %s", err.Error()),
+		})
+	}
+	existing, similar := matchTagName(entries, name, uuid.Nil)
+	if existing != nil {
+		return echo.NewHTTPError(http.StatusConflict, TagConflictError{
+			Message:     "Tag with this name already exists.",
+			ExistingTag: *existing,
+		})
+	}
+
+	status := h.newTagStatus(creatorID)
+	newTag, err := h.repo.CreateTags(ctx, repository.CreateTagParams{
+		Name:      name,
 		CreatorID: creatorID,
+		Status:    status,
 	})
 	if err != nil {
 		if errors.Is(err, repository.ErrTagConflict) {
@@ -96,9 +224,12 @@ func (h *Handler) createTags(c echo.Context) error {
 		})
 	}
 
-	response := TagSummary{
-		Id:   newTag,
-		Name: body.Name,
+	h.policy.Record(creatorID, contentpolicy.KindTag)
+
+	response := CreateTagResponse{
+		TagSummary:  TagSummary{Id: newTag, Name: name},
+		Status:      status,
+		SimilarTags: similar,
 	}
 
 	return c.JSON(http.StatusCreated, response)
@@ -113,7 +244,9 @@ func (h *Handler) getTagDetails(c echo.Context) error {
 		})
 	}
 
-	tagDetailsRaw, err := h.repo.GetTagDetails(c.Request().Context(), tagID)
+	// 非表示にされたタグは管理者以外には見つからないものとして扱う
+	userID, _ := c.Get(userIDContextKey).(uuid.UUID)
+	tagDetailsRaw, err := h.repo.GetTagDetails(c.Request().Context(), tagID, h.isAdmin(userID))
 	if err != nil {
 		if errors.Is(err, repository.ErrTagNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound, Error{
@@ -148,10 +281,24 @@ func (h *Handler) getTagDetails(c echo.Context) error {
 		CreatorId: tagDetails.CreatorID,
 		CreatedAt: tagDetails.CreatedAt,
 		UpdatedAt: tagDetails.UpdatedAt,
+		ParentId:  tagDetails.ParentID,
+		Status:    tagDetails.Status,
 		Count:     len(stamps),
+		Aliases:   tagDetails.Aliases,
 		Stamps:    stamps,
 	}
 
+	revisions, err := h.repo.GetTagWikiRevisions(c.Request().Context(), tagDetails.ID, tagWikiHistoryLimit)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, Error{
+			Message: fmt.Sprintf("failed to get tag wiki: %s", err.Error()),
+		})
+	}
+	if len(revisions) > 0 {
+		response.Wiki = newTagWiki(revisions[0])
+	}
+	response.WikiHistory = newTagWikiHistory(revisions)
+
 	return c.JSON(http.StatusOK, response)
 }
 
@@ -171,7 +318,35 @@ func (h *Handler) updateTags(c echo.Context) error {
 		})
 	}
 
-	err = h.repo.UpdateTags(c.Request().Context(), tagID, body.Name)
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+	name, err := normalizeTagName(body.Name)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: err.Error(),
+		})
+	}
+	if v := h.policy.CheckTagName(userID, name); v != nil {
+		return c.JSON(http.StatusUnprocessableEntity, v)
+	}
+
+	ctx := c.Request().Context()
+	entries, err := h.repo.GetTagNameEntries(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, Error{
+			Message: fmt.Sprintf("failed to update tag: %s", err.Error()),
+		})
+	}
+	if existing, _ := matchTagName(entries, name, tagID); existing != nil {
+		return echo.NewHTTPError(http.StatusConflict, TagConflictError{
+			Message:     "Tag with this name already exists.",
+			ExistingTag: *existing,
+		})
+	}
+
+	err = h.repo.UpdateTags(ctx, tagID, name)
 	if err != nil {
 		if errors.Is(err, repository.ErrTagNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound, Error{
@@ -188,6 +363,7 @@ func (h *Handler) updateTags(c echo.Context) error {
 			Message: fmt.Sprintf("failed to update tag: %s", err.Error()),
 		})
 	}
+	h.policy.Record(userID, contentpolicy.KindTag)
 
 	return c.NoContent(http.StatusNoContent)
 }
@@ -233,7 +409,8 @@ func (h *Handler) getStampsByTag(c echo.Context) error {
 		})
 	}
 
-	stampSummaries, err := h.repo.GetStampsByTagID(c.Request().Context(), tagID)
+	includeDescendants := c.QueryParam("include_descendants") == "true"
+	stampSummaries, err := h.repo.GetStampsByTagID(c.Request().Context(), tagID, includeDescendants)
 	if err != nil {
 		if errors.Is(err, repository.ErrTagNotFound) {
 			return echo.NewHTTPError(http.StatusNotFound, Error{
@@ -257,3 +434,103 @@ func (h *Handler) getStampsByTag(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, response)
 }
+
+func (h *Handler) mergeTags(c echo.Context) error {
+	sourceID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid tag ID format.",
+		})
+	}
+	targetID, err := uuid.Parse(c.Param("targetId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid target tag ID format.",
+		})
+	}
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+
+	if err := h.repo.MergeTags(c.Request().Context(), sourceID, targetID, userID); err != nil {
+		if errors.Is(err, repository.ErrTagMergeIntoSelf) {
+			return echo.NewHTTPError(http.StatusBadRequest, Error{
+				Message: "Cannot merge a tag into itself.",
+			})
+		}
+		if errors.Is(err, repository.ErrTagNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag not found.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, Error{
+			Message: fmt.Sprintf("failed to merge tags: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) lookupTag(c echo.Context) error {
+	name := c.QueryParam("name")
+	if name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "name is required.",
+		})
+	}
+
+	tag, err := h.repo.GetTagByName(c.Request().Context(), name)
+	if err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag not found.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, Error{
+			Message: fmt.Sprintf("failed to look up tag: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, TagSummary{
+		Id:   tag.ID,
+		Name: tag.Name,
+	})
+}
+
+func (h *Handler) updateTagParent(c echo.Context) error {
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid tag ID format.",
+		})
+	}
+
+	var body PutTagsTagIdParentJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid request body.",
+		})
+	}
+
+	if err := h.repo.SetTagParent(c.Request().Context(), tagID, body.ParentID); err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag not found.",
+			})
+		}
+		if errors.Is(err, repository.ErrTagCycle) {
+			return echo.NewHTTPError(http.StatusConflict, Error{
+				Message: "The parent tag is the tag itself or one of its descendants.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError, Error{
+			Message: fmt.Sprintf("failed to update tag parent: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+// maxTagBatchStamps は1回の一括操作で指定できるスタンプ数の上限
+const maxTagBatchStamps = 1000
+
+type (
+	PostTagsTagIdStampsBatchJSONRequestBody struct {
+		Action   string      `json:"action"`
+		StampIDs []uuid.UUID `json:"stamp_ids"`
+	}
+
+	TagBatchResponse struct {
+		OperationID uuid.UUID                       `json:"operation_id"`
+		TagID       uuid.UUID                       `json:"tag_id"`
+		Action      string                          `json:"action"`
+		Results     []repository.TagBatchItemResult `json:"results"`
+	}
+)
+
+func (h *Handler) batchUpdateStampTags(c echo.Context) error {
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid tag ID format.",
+		})
+	}
+	var body PostTagsTagIdStampsBatchJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid request body.",
+		})
+	}
+	if body.Action != repository.TagBatchActionAdd && body.Action != repository.TagBatchActionRemove {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "action must be one of add, remove.",
+		})
+	}
+	if len(body.StampIDs) == 0 || len(body.StampIDs) > maxTagBatchStamps {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: fmt.Sprintf("stamp_ids must contain 1 to %d items.", maxTagBatchStamps),
+		})
+	}
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+
+	ctx := c.Request().Context()
+	tagID, err = h.repo.ResolveTagID(ctx, tagID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	operation, results, err := h.repo.BatchUpdateStampTags(ctx, tagID, body.Action, body.StampIDs, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag not found.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	if body.Action == repository.TagBatchActionAdd {
+		h.approveTagIfWidelyUsed(c, tagID)
+	}
+
+	return c.JSON(http.StatusOK, TagBatchResponse{
+		OperationID: operation.ID,
+		TagID:       operation.TagID,
+		Action:      operation.Action,
+		Results:     results,
+	})
+}
+
+// undoTagBatchOperation は一括操作をまとめて取り消す。操作したユーザーか管理者のみ実行できる。
+func (h *Handler) undoTagBatchOperation(c echo.Context) error {
+	operationID, err := uuid.Parse(c.Param("operationId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid operation ID format.",
+		})
+	}
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+
+	ctx := c.Request().Context()
+	operation, err := h.repo.GetTagBatchOperation(ctx, operationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTagBatchOperationNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Batch operation not found.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	if operation.CreatorID != userID && !h.isAdmin(userID) {
+		return echo.NewHTTPError(http.StatusForbidden, Error{
+			Message: "Only the user who performed the operation can undo it.",
+		})
+	}
+
+	if err := h.repo.UndoTagBatchOperation(ctx, operationID); err != nil {
+		if errors.Is(err, repository.ErrTagBatchOperationNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Batch operation not found.",
+			})
+		}
+		if errors.Is(err, repository.ErrTagBatchOperationUndone) {
+			return echo.NewHTTPError(http.StatusConflict, Error{
+				Message: "Batch operation has already been undone.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+// tagCategoryNameMaxLength は tag_categories.name のカラム長
+const tagCategoryNameMaxLength = 32
+
+var tagCategoryColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type tagCategoryPayload struct {
+	Name         string `json:"category_name"`
+	Color        string `json:"color"`
+	DisplayOrder int    `json:"display_order"`
+}
+
+type PutTagsTagIdCategoryJSONRequestBody struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+}
+
+type TagCategorySummary struct {
+	Id           uuid.UUID `json:"category_id"`
+	Name         string    `json:"category_name"`
+	Color        string    `json:"color"`
+	DisplayOrder int       `json:"display_order"`
+}
+
+// TagGroup はスタンプ詳細でカテゴリごとにまとめたタグ (Category が nil のものはカテゴリなし)
+type TagGroup struct {
+	Category *TagCategorySummary      `json:"category"`
+	Tags     []*repository.TagSummary `json:"tags"`
+}
+
+func (p tagCategoryPayload) validate() error {
+	if p.Name == "" || utf8.RuneCountInString(p.Name) > tagCategoryNameMaxLength {
+		return fmt.Errorf("category_name must be 1 to %d characters", tagCategoryNameMaxLength)
+	}
+	if !tagCategoryColorPattern.MatchString(p.Color) {
+		return errors.New("color must be in #RRGGBB format")
+	}
+
+	return nil
+}
+
+func (h *Handler) getTagCategories(c echo.Context) error {
+	categories, err := h.repo.GetTagCategories(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, categories)
+}
+
+func (h *Handler) createTagCategory(c echo.Context) error {
+	var payload tagCategoryPayload
+	if err := c.Bind(&payload); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid request body.",
+		})
+	}
+	if err := payload.validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: err.Error(),
+		})
+	}
+
+	category, err := h.repo.CreateTagCategory(c.Request().Context(), repository.TagCategoryParams{
+		Name:         payload.Name,
+		Color:        payload.Color,
+		DisplayOrder: payload.DisplayOrder,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrTagCategoryConflict) {
+			return echo.NewHTTPError(http.StatusConflict, Error{
+				Message: "Tag category with this name already exists.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, category)
+}
+
+func (h *Handler) updateTagCategory(c echo.Context) error {
+	categoryID, err := uuid.Parse(c.Param("categoryId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid category ID format.",
+		})
+	}
+	var payload tagCategoryPayload
+	if err := c.Bind(&payload); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid request body.",
+		})
+	}
+	if err := payload.validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: err.Error(),
+		})
+	}
+
+	err = h.repo.UpdateTagCategory(c.Request().Context(), categoryID, repository.TagCategoryParams{
+		Name:         payload.Name,
+		Color:        payload.Color,
+		DisplayOrder: payload.DisplayOrder,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrTagCategoryNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag category not found.",
+			})
+		}
+		if errors.Is(err, repository.ErrTagCategoryConflict) {
+			return echo.NewHTTPError(http.StatusConflict, Error{
+				Message: "Tag category with this name already exists.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) deleteTagCategory(c echo.Context) error {
+	categoryID, err := uuid.Parse(c.Param("categoryId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid category ID format.",
+		})
+	}
+
+	if err := h.repo.DeleteTagCategory(c.Request().Context(), categoryID); err != nil {
+		if errors.Is(err, repository.ErrTagCategoryNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag category not found.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) updateTagCategoryOfTag(c echo.Context) error {
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid tag ID format.",
+		})
+	}
+	var body PutTagsTagIdCategoryJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid request body.",
+		})
+	}
+
+	if err := h.repo.SetTagCategory(c.Request().Context(), tagID, body.CategoryID); err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag not found.",
+			})
+		}
+		if errors.Is(err, repository.ErrTagCategoryNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag category not found.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// groupTagsByCategory はカテゴリの表示順に並んだタグをカテゴリごとにまとめる
+func groupTagsByCategory(tags []*repository.CategorizedTag) []*TagGroup {
+	groups := []*TagGroup{}
+	var current *TagGroup
+	for _, t := range tags {
+		if current == nil || !sameCategory(current.Category, t.CategoryID) {
+			current = &TagGroup{Tags: []*repository.TagSummary{}}
+			if t.CategoryID != nil {
+				current.Category = &TagCategorySummary{
+					Id:           *t.CategoryID,
+					Name:         *t.CategoryName,
+					Color:        *t.CategoryColor,
+					DisplayOrder: *t.CategoryDisplayOrder,
+				}
+			}
+			groups = append(groups, current)
+		}
+		current.Tags = append(current.Tags, &repository.TagSummary{ID: t.ID, Name: t.Name})
+	}
+
+	return groups
+}
+
+func sameCategory(category *TagCategorySummary, categoryID *uuid.UUID) bool {
+	if category == nil || categoryID == nil {
+		return category == nil && categoryID == nil
+	}
+
+	return category.Id == *categoryID
+}
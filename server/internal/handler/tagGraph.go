@@ -0,0 +1,333 @@
+package handler
+
+import (
+	"encoding/xml"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+const (
+	defaultCooccurringLimit = 20
+	maxCooccurringLimit     = 100
+	graphMLContentType      = "application/graphml+xml; charset=utf-8"
+	graphMLNamespace        = "http://graphml.graphdrawing.org/xmlns"
+)
+
+type (
+	getCooccurringTagsParams struct {
+		Sort     *string `query:"sort"`
+		MinCount *int    `query:"min_count"`
+		Limit    *int    `query:"limit"`
+	}
+
+	getTagGraphParams struct {
+		Format   *string `query:"format"`
+		MinCount *int    `query:"min_count"`
+	}
+
+	CooccurringTag struct {
+		Id       uuid.UUID `json:"tag_id"`
+		Name     string    `json:"tag_name"`
+		Count    int       `json:"count"`
+		TagCount int       `json:"tag_count"`
+		Lift     float64   `json:"lift"`
+		PMI      float64   `json:"pmi"`
+	}
+
+	CooccurringTagsResponse struct {
+		Id          uuid.UUID         `json:"tag_id"`
+		Name        string            `json:"tag_name"`
+		Count       int               `json:"count"`
+		StampCount  int               `json:"stamp_count"`
+		ComputedAt  time.Time         `json:"computed_at"`
+		Cooccurring []*CooccurringTag `json:"cooccurring"`
+	}
+
+	TagGraphNode struct {
+		Id    uuid.UUID `json:"tag_id"`
+		Name  string    `json:"tag_name"`
+		Count int       `json:"count"`
+	}
+
+	TagGraphEdge struct {
+		Source uuid.UUID `json:"source"`
+		Target uuid.UUID `json:"target"`
+		Count  int       `json:"count"`
+		Lift   float64   `json:"lift"`
+		PMI    float64   `json:"pmi"`
+	}
+
+	TagGraphResponse struct {
+		StampCount int            `json:"stamp_count"`
+		ComputedAt time.Time      `json:"computed_at"`
+		Nodes      []TagGraphNode `json:"nodes"`
+		Edges      []TagGraphEdge `json:"edges"`
+	}
+
+	graphML struct {
+		XMLName xml.Name     `xml:"graphml"`
+		Xmlns   string       `xml:"xmlns,attr"`
+		Keys    []graphMLKey `xml:"key"`
+		Graph   graphMLGraph `xml:"graph"`
+	}
+
+	graphMLKey struct {
+		ID       string `xml:"id,attr"`
+		For      string `xml:"for,attr"`
+		AttrName string `xml:"attr.name,attr"`
+		AttrType string `xml:"attr.type,attr"`
+	}
+
+	graphMLGraph struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	}
+
+	graphMLNode struct {
+		ID   string        `xml:"id,attr"`
+		Data []graphMLData `xml:"data"`
+	}
+
+	graphMLEdge struct {
+		Source string        `xml:"source,attr"`
+		Target string        `xml:"target,attr"`
+		Data   []graphMLData `xml:"data"`
+	}
+
+	graphMLData struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// cooccurrenceScores は共起数から lift と PMI (log2 lift) を求める
+func cooccurrenceScores(count int, countA int, countB int, stampCount int) (float64, float64) {
+	lift := float64(count) * float64(stampCount) / (float64(countA) * float64(countB))
+
+	return lift, math.Log2(lift)
+}
+
+func (h *Handler) getCooccurringTags(c echo.Context) error {
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid tag ID format.",
+		})
+	}
+	var params getCooccurringTagsParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid query parameters.",
+		})
+	}
+	sortKey := "count"
+	if params.Sort != nil {
+		switch *params.Sort {
+		case "count", "lift", "pmi":
+			sortKey = *params.Sort
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, Error{
+				Message: "sort must be one of count, lift, pmi.",
+			})
+		}
+	}
+	minCount := 1
+	if params.MinCount != nil {
+		minCount = max(*params.MinCount, 1)
+	}
+	limit := defaultCooccurringLimit
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxCooccurringLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, Error{
+				Message: "limit must be between 1 and 100.",
+			})
+		}
+		limit = *params.Limit
+	}
+
+	ctx := c.Request().Context()
+	tagID, err = h.repo.ResolveTagID(ctx, tagID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	graph, err := h.repo.GetTagGraph(ctx)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	nodes := make(map[uuid.UUID]repository.TagGraphNode, len(graph.Nodes))
+	for _, n := range graph.Nodes {
+		nodes[n.ID] = n
+	}
+	tag, ok := nodes[tagID]
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, Error{
+			Message: "Tag not found.",
+		})
+	}
+
+	cooccurring := []*CooccurringTag{}
+	for _, e := range graph.Edges {
+		if e.Count < minCount {
+			continue
+		}
+		var otherID uuid.UUID
+		switch tagID {
+		case e.SourceID:
+			otherID = e.TargetID
+		case e.TargetID:
+			otherID = e.SourceID
+		default:
+			continue
+		}
+		other := nodes[otherID]
+		lift, pmi := cooccurrenceScores(e.Count, tag.Count, other.Count, graph.StampCount)
+		cooccurring = append(cooccurring, &CooccurringTag{
+			Id:       other.ID,
+			Name:     other.Name,
+			Count:    e.Count,
+			TagCount: other.Count,
+			Lift:     lift,
+			PMI:      pmi,
+		})
+	}
+	sort.SliceStable(cooccurring, func(i, j int) bool {
+		a, b := cooccurring[i], cooccurring[j]
+		switch {
+		case sortKey == "count" && a.Count != b.Count:
+			return a.Count > b.Count
+		case sortKey != "count" && a.Lift != b.Lift:
+			// PMI は lift の単調増加関数なので lift で比較すればよい
+			return a.Lift > b.Lift
+		}
+
+		return a.Name < b.Name
+	})
+	if len(cooccurring) > limit {
+		cooccurring = cooccurring[:limit]
+	}
+
+	return c.JSON(http.StatusOK, CooccurringTagsResponse{
+		Id:          tag.ID,
+		Name:        tag.Name,
+		Count:       tag.Count,
+		StampCount:  graph.StampCount,
+		ComputedAt:  graph.ComputedAt,
+		Cooccurring: cooccurring,
+	})
+}
+
+func (h *Handler) getTagGraph(c echo.Context) error {
+	var params getTagGraphParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid query parameters.",
+		})
+	}
+	format := "json"
+	if params.Format != nil {
+		switch *params.Format {
+		case "json", "graphml":
+			format = *params.Format
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, Error{
+				Message: "format must be one of json, graphml.",
+			})
+		}
+	}
+	minCount := 1
+	if params.MinCount != nil {
+		minCount = max(*params.MinCount, 1)
+	}
+
+	graph, err := h.repo.GetTagGraph(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	counts := make(map[uuid.UUID]int, len(graph.Nodes))
+	response := TagGraphResponse{
+		StampCount: graph.StampCount,
+		ComputedAt: graph.ComputedAt,
+		Nodes:      make([]TagGraphNode, len(graph.Nodes)),
+		Edges:      []TagGraphEdge{},
+	}
+	for i, n := range graph.Nodes {
+		counts[n.ID] = n.Count
+		response.Nodes[i] = TagGraphNode{Id: n.ID, Name: n.Name, Count: n.Count}
+	}
+	for _, e := range graph.Edges {
+		if e.Count < minCount {
+			continue
+		}
+		lift, pmi := cooccurrenceScores(e.Count, counts[e.SourceID], counts[e.TargetID], graph.StampCount)
+		response.Edges = append(response.Edges, TagGraphEdge{
+			Source: e.SourceID,
+			Target: e.TargetID,
+			Count:  e.Count,
+			Lift:   lift,
+			PMI:    pmi,
+		})
+	}
+
+	if format == "graphml" {
+		return writeGraphML(c, response)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+func writeGraphML(c echo.Context, graph TagGraphResponse) error {
+	doc := graphML{
+		Xmlns: graphMLNamespace,
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", AttrName: "name", AttrType: "string"},
+			{ID: "tag_count", For: "node", AttrName: "count", AttrType: "int"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "int"},
+			{ID: "lift", For: "edge", AttrName: "lift", AttrType: "double"},
+			{ID: "pmi", For: "edge", AttrName: "pmi", AttrType: "double"},
+		},
+		Graph: graphMLGraph{
+			ID:          "tags",
+			EdgeDefault: "undirected",
+			Nodes:       make([]graphMLNode, len(graph.Nodes)),
+			Edges:       make([]graphMLEdge, len(graph.Edges)),
+		},
+	}
+	for i, n := range graph.Nodes {
+		doc.Graph.Nodes[i] = graphMLNode{
+			ID: n.Id.String(),
+			Data: []graphMLData{
+				{Key: "name", Value: n.Name},
+				{Key: "tag_count", Value: strconv.Itoa(n.Count)},
+			},
+		}
+	}
+	for i, e := range graph.Edges {
+		doc.Graph.Edges[i] = graphMLEdge{
+			Source: e.Source.String(),
+			Target: e.Target.String(),
+			Data: []graphMLData{
+				{Key: "weight", Value: strconv.Itoa(e.Count)},
+				{Key: "lift", Value: strconv.FormatFloat(e.Lift, 'g', -1, 64)},
+				{Key: "pmi", Value: strconv.FormatFloat(e.PMI, 'g', -1, 64)},
+			},
+		}
+	}
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.Blob(http.StatusOK, graphMLContentType, append([]byte(xml.Header), body...))
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// tagNameMaxLength は tags.name のカラム長
+	tagNameMaxLength = 32
+	// tagNameForbiddenChars はタグ名に使えない文字 (区切り文字やスタンプ記法と紛らわしいもの)
+	tagNameForbiddenChars = ",:<>"
+	maxSimilarTags        = 5
+)
+
+type (
+	SimilarTag struct {
+		Id       uuid.UUID `json:"tag_id"`
+		Name     string    `json:"tag_name"`
+		Distance int       `json:"distance"`
+	}
+
+	CreateTagResponse struct {
+		TagSummary
+		Status      string       `json:"status"`
+		SimilarTags []SimilarTag `json:"similar_tags"`
+	}
+
+	TagConflictError struct {
+		Message     string     `json:"message"`
+		ExistingTag TagSummary `json:"existing_tag"`
+	}
+)
+
+// foldTagName は NFKC 正規化し、前後の空白を除いて連続する空白を1つにまとめる
+func foldTagName(name string) string {
+	return strings.Join(strings.Fields(norm.NFKC.String(name)), " ")
+}
+
+// normalizeTagName はタグ名を正規化し、タグ名として使えない場合はエラーを返す
+func normalizeTagName(name string) (string, error) {
+	if !utf8.ValidString(name) {
+		return "", errors.New("tag name must be valid UTF-8")
+	}
+	name = foldTagName(name)
+	if name == "" {
+		return "", errors.New("tag name must not be empty")
+	}
+	if utf8.RuneCountInString(name) > tagNameMaxLength {
+		return "", fmt.Errorf("tag name must be at most %d characters", tagNameMaxLength)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) || strings.ContainsRune(tagNameForbiddenChars, r) {
+			return "", fmt.Errorf("tag name must not contain %q", r)
+		}
+	}
+
+	return name, nil
+}
+
+// matchTagName は正規化済みのタグ名 name について、正規化後に一致する既存のタグと、編集距離が近いタグを返す。
+// excludeID のタグは一致・類似の対象から除く。
+func matchTagName(entries []repository.TagNameEntry, name string, excludeID uuid.UUID) (*TagSummary, []SimilarTag) {
+	lowerName := strings.ToLower(name)
+	maxDistance := similarTagMaxDistance(utf8.RuneCountInString(name))
+	similar := map[uuid.UUID]SimilarTag{}
+	for _, e := range entries {
+		if e.TagID == excludeID {
+			continue
+		}
+		folded := foldTagName(e.Name)
+		if folded == name {
+			return &TagSummary{Id: e.TagID, Name: e.TagName}, nil
+		}
+		distance := editDistance(lowerName, strings.ToLower(folded))
+		if distance > maxDistance {
+			continue
+		}
+		if s, ok := similar[e.TagID]; !ok || distance < s.Distance {
+			similar[e.TagID] = SimilarTag{Id: e.TagID, Name: e.TagName, Distance: distance}
+		}
+	}
+
+	result := make([]SimilarTag, 0, len(similar))
+	for _, s := range similar {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Distance != result[j].Distance {
+			return result[i].Distance < result[j].Distance
+		}
+
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > maxSimilarTags {
+		result = result[:maxSimilarTags]
+	}
+
+	return nil, result
+}
+
+// similarTagMaxDistance は類似とみなす編集距離の上限。短い名前ほど厳しくする。
+func similarTagMaxDistance(length int) int {
+	switch {
+	case length <= 2:
+		return 0
+	case length <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance は文字単位のレーベンシュタイン距離を返す
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
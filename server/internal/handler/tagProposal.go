@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+	"github.com/traP-jp/1m25_11/server/pkg/config"
+)
+
+const rejectReasonMaxLength = 200
+
+type PostTagsTagIdRejectJSONRequestBody struct {
+	Reason string `json:"reason"`
+}
+
+// newTagStatus は作成するタグの状態を返す。提案モードでは管理者以外が作成したタグは提案中になる
+func (h *Handler) newTagStatus(userID uuid.UUID) string {
+	if config.TagProposalMode() && !h.isAdmin(userID) {
+		return repository.TagStatusProposed
+	}
+
+	return repository.TagStatusApproved
+}
+
+// approveTagIfWidelyUsed はタグ付けの後に呼び出し、提案中のタグを十分な人数が使っていれば承認する。
+// タグ付け自体は成功しているので、失敗してもログに残すだけにする。
+func (h *Handler) approveTagIfWidelyUsed(c echo.Context, tagID uuid.UUID) {
+	if _, err := h.repo.ApproveTagIfWidelyUsed(c.Request().Context(), tagID, config.TagApprovalThreshold()); err != nil {
+		log.Printf("failed to check tag approval (tagID=%s): %v", tagID, err)
+	}
+}
+
+func (h *Handler) getTagProposals(c echo.Context) error {
+	proposals, err := h.repo.GetTagProposals(c.Request().Context())
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, proposals)
+}
+
+func (h *Handler) approveTag(c echo.Context) error {
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid tag ID format.",
+		})
+	}
+
+	if err := h.repo.ApproveTag(c.Request().Context(), tagID); err != nil {
+		return tagProposalError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handler) rejectTag(c echo.Context) error {
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid tag ID format.",
+		})
+	}
+	var body PostTagsTagIdRejectJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid request body.",
+		})
+	}
+	if utf8.RuneCountInString(body.Reason) > rejectReasonMaxLength {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: fmt.Sprintf("reason must be at most %d characters.", rejectReasonMaxLength),
+		})
+	}
+
+	if err := h.repo.RejectTag(c.Request().Context(), tagID, body.Reason); err != nil {
+		return tagProposalError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func tagProposalError(err error) error {
+	if errors.Is(err, repository.ErrTagNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, Error{
+			Message: "Tag not found.",
+		})
+	}
+	if errors.Is(err, repository.ErrTagNotProposed) {
+		return echo.NewHTTPError(http.StatusConflict, Error{
+			Message: "Tag is not a proposal.",
+		})
+	}
+
+	return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+}
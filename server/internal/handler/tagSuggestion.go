@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultTagSuggestionLimit = 10
+	maxTagSuggestionLimit     = 50
+	// suggestionKeywordMinLength より短いスタンプ名の断片は関連スタンプの検索に使わない
+	suggestionKeywordMinLength = 3
+	maxSuggestionKeywords      = 5
+	recentTagSuggestionCount   = 20
+	// recentTagBaseScore は最近使ったタグのうち最も新しいものの確信度
+	recentTagBaseScore = 0.4
+)
+
+// タグ候補の根拠
+const (
+	suggestionReasonRelatedStamps = "related_stamps"
+	suggestionReasonCooccurrence  = "cooccurrence"
+	suggestionReasonRecent        = "recent"
+)
+
+type (
+	getTagSuggestionsParams struct {
+		Limit *int `query:"limit"`
+	}
+
+	TagSuggestion struct {
+		Id         uuid.UUID `json:"tag_id"`
+		Name       string    `json:"tag_name"`
+		Confidence float64   `json:"confidence"`
+		Reasons    []string  `json:"reasons"`
+	}
+)
+
+// tagSuggestionSet は根拠ごとの確信度を noisy-OR で1つの確信度にまとめる
+type tagSuggestionSet map[uuid.UUID]*TagSuggestion
+
+func (s tagSuggestionSet) add(id uuid.UUID, name string, reason string, score float64) {
+	suggestion, ok := s[id]
+	if !ok {
+		suggestion = &TagSuggestion{Id: id, Name: name, Reasons: []string{}}
+		s[id] = suggestion
+	}
+	suggestion.Confidence = 1 - (1-suggestion.Confidence)*(1-score)
+	suggestion.Reasons = append(suggestion.Reasons, reason)
+}
+
+// suggestionKeywords はスタンプ名を英数字以外で区切り、関連スタンプの検索に使う語を返す
+func suggestionKeywords(stampName string) []string {
+	keywords := []string{}
+	seen := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(stampName), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if utf8.RuneCountInString(w) < suggestionKeywordMinLength || seen[w] {
+			continue
+		}
+		seen[w] = true
+		keywords = append(keywords, w)
+		if len(keywords) == maxSuggestionKeywords {
+			break
+		}
+	}
+
+	return keywords
+}
+
+func (h *Handler) getTagSuggestions(c echo.Context) error {
+	stampID, err := uuid.Parse(c.Param("stampId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid stamp ID").SetInternal(err)
+	}
+	var params getTagSuggestionsParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+	limit := defaultTagSuggestionLimit
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxTagSuggestionLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 50")
+		}
+		limit = *params.Limit
+	}
+	userID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+
+	ctx := c.Request().Context()
+	stamp, err := h.repo.GetStampByStampID(ctx, stampID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "stamp not found")
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	linkedTags, err := h.repo.GetTagsByStampID(ctx, stampID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	linked := make(map[uuid.UUID]bool, len(linkedTags))
+	for _, t := range linkedTags {
+		linked[t.ID] = true
+	}
+
+	suggestions := tagSuggestionSet{}
+
+	// (a) 名前や説明文が似ているスタンプに付いているタグ: 関連スタンプのうちそのタグが付いている割合
+	relatedTags, relatedCount, err := h.repo.GetTagsOnRelatedStamps(ctx, stampID, suggestionKeywords(stamp.Name))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	for _, t := range relatedTags {
+		suggestions.add(t.ID, t.Name, suggestionReasonRelatedStamps, float64(t.Count)/float64(relatedCount))
+	}
+
+	// (b) 既に付いているタグと一緒に付けられやすいタグ: 既存タグ t に対する P(候補 | t) の最大値
+	if len(linkedTags) > 0 {
+		graph, err := h.repo.GetTagGraph(ctx)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+		}
+		names := make(map[uuid.UUID]string, len(graph.Nodes))
+		counts := make(map[uuid.UUID]int, len(graph.Nodes))
+		for _, n := range graph.Nodes {
+			names[n.ID] = n.Name
+			counts[n.ID] = n.Count
+		}
+		scores := map[uuid.UUID]float64{}
+		for _, e := range graph.Edges {
+			for _, pair := range [][2]uuid.UUID{{e.SourceID, e.TargetID}, {e.TargetID, e.SourceID}} {
+				from, to := pair[0], pair[1]
+				if !linked[from] || linked[to] || counts[from] == 0 {
+					continue
+				}
+				scores[to] = max(scores[to], float64(e.Count)/float64(counts[from]))
+			}
+		}
+		for id, score := range scores {
+			suggestions.add(id, names[id], suggestionReasonCooccurrence, score)
+		}
+	}
+
+	// (c) ユーザーが最近付けたタグ: 新しいものほど高い確信度
+	recentTags, err := h.repo.GetRecentTagsByUser(ctx, userID, recentTagSuggestionCount)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	for i, t := range recentTags {
+		if linked[t.ID] {
+			continue
+		}
+		score := recentTagBaseScore * float64(len(recentTags)-i) / float64(len(recentTags))
+		suggestions.add(t.ID, t.Name, suggestionReasonRecent, score)
+	}
+
+	result := make([]*TagSuggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Confidence != result[j].Confidence {
+			return result[i].Confidence > result[j].Confidence
+		}
+
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/traP-jp/1m25_11/server/internal/repository"
+)
+
+const (
+	tagWikiBodyMaxLength    = 10000
+	tagWikiCommentMaxLength = 100
+	// tagWikiHistoryLimit はタグ詳細で返す編集履歴の件数
+	tagWikiHistoryLimit = 50
+)
+
+type (
+	PutTagsTagIdWikiJSONRequestBody struct {
+		Body                  string     `json:"body"`
+		RepresentativeStampID *uuid.UUID `json:"representative_stamp_id"`
+		Comment               string     `json:"comment"`
+		BaseRevision          *int       `json:"base_revision"`
+	}
+
+	TagWiki struct {
+		Revision            int           `json:"revision"`
+		Body                string        `json:"body"`
+		RepresentativeStamp *StampSummary `json:"representative_stamp"`
+		EditorId            uuid.UUID     `json:"editor_id"`
+		UpdatedAt           time.Time     `json:"updated_at"`
+	}
+
+	TagWikiRevision struct {
+		Revision              int        `json:"revision"`
+		Body                  string     `json:"body"`
+		RepresentativeStampId *uuid.UUID `json:"representative_stamp_id"`
+		EditorId              uuid.UUID  `json:"editor_id"`
+		Comment               string     `json:"comment"`
+		CreatedAt             time.Time  `json:"created_at"`
+	}
+)
+
+// newTagWiki は最新の版から現在のwikiを作る
+func newTagWiki(latest *repository.TagWikiRevision) *TagWiki {
+	wiki := &TagWiki{
+		Revision:  latest.Revision,
+		Body:      latest.Body,
+		EditorId:  latest.EditorID,
+		UpdatedAt: latest.CreatedAt,
+	}
+	if latest.RepresentativeStampID != nil && latest.RepresentativeStampName != nil && latest.RepresentativeStampFileID != nil {
+		wiki.RepresentativeStamp = &StampSummary{
+			Id:     *latest.RepresentativeStampID,
+			Name:   *latest.RepresentativeStampName,
+			FileId: *latest.RepresentativeStampFileID,
+		}
+	}
+
+	return wiki
+}
+
+func newTagWikiHistory(revisions []*repository.TagWikiRevision) []TagWikiRevision {
+	history := make([]TagWikiRevision, len(revisions))
+	for i, r := range revisions {
+		history[i] = TagWikiRevision{
+			Revision:              r.Revision,
+			Body:                  r.Body,
+			RepresentativeStampId: r.RepresentativeStampID,
+			EditorId:              r.EditorID,
+			Comment:               r.Comment,
+			CreatedAt:             r.CreatedAt,
+		}
+	}
+
+	return history
+}
+
+func (h *Handler) updateTagWiki(c echo.Context) error {
+	tagID, err := uuid.Parse(c.Param("tagId"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid tag ID format.",
+		})
+	}
+	var body PutTagsTagIdWikiJSONRequestBody
+	if err := c.Bind(&body); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: "Invalid request body.",
+		})
+	}
+	if utf8.RuneCountInString(body.Body) > tagWikiBodyMaxLength {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: fmt.Sprintf("body must be at most %d characters.", tagWikiBodyMaxLength),
+		})
+	}
+	if utf8.RuneCountInString(body.Comment) > tagWikiCommentMaxLength {
+		return echo.NewHTTPError(http.StatusBadRequest, Error{
+			Message: fmt.Sprintf("comment must be at most %d characters.", tagWikiCommentMaxLength),
+		})
+	}
+	editorID, ok := c.Get(userIDContextKey).(uuid.UUID)
+	if !ok {
+		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+	}
+
+	ctx := c.Request().Context()
+	tagID, err = h.repo.ResolveTagID(ctx, tagID)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+	_, err = h.repo.SaveTagWiki(ctx, repository.SaveTagWikiParams{
+		TagID:                 tagID,
+		Body:                  body.Body,
+		RepresentativeStampID: body.RepresentativeStampID,
+		EditorID:              editorID,
+		Comment:               body.Comment,
+		BaseRevision:          body.BaseRevision,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, Error{
+				Message: "Tag not found.",
+			})
+		}
+		if errors.Is(err, repository.ErrStampNotFound) {
+			return echo.NewHTTPError(http.StatusBadRequest, Error{
+				Message: "Representative stamp not found.",
+			})
+		}
+		if errors.Is(err, repository.ErrTagWikiConflict) {
+			return echo.NewHTTPError(http.StatusConflict, Error{
+				Message: "The wiki has been edited by someone else. Please reload and try again.",
+			})
+		}
+
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	revisions, err := h.repo.GetTagWikiRevisions(ctx, tagID, 1)
+	if err != nil || len(revisions) == 0 {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, newTagWiki(revisions[0]))
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/traP-jp/1m25_11/server/internal/repository"
@@ -16,6 +17,16 @@ func (h *Handler) Test(ctx context.Context) {
 	log.Println("Starting test")
 }
 
+// RebuildDescriptionReferences は説明文中のスタンプ参照を作り直す
+func (h *Handler) RebuildDescriptionReferences(ctx context.Context) {
+	if err := h.repo.RebuildDescriptionReferences(ctx); err != nil {
+		log.Printf("Error rebuilding description references: %v", err)
+
+		return
+	}
+	log.Println("Successfully rebuilt description references")
+}
+
 func (h *Handler) CronJobTask(ctx context.Context) {
 
 	bot_key, ok := os.LookupEnv("BOT_TOKEN_KEY")
@@ -61,6 +72,9 @@ func (h *Handler) CronJobTask(ctx context.Context) {
 
 	log.Println("successfully cronJobTask")
 
+	// スタンプの追加や名前の変更に説明文中のスタンプ参照を追従させる
+	h.RebuildDescriptionReferences(ctx)
+
 	stampTotalCount := make(map[uuid.UUID]int)
 	allStamps, err := h.repo.GetStampSummaries(ctx)
 	if err != nil {
@@ -116,4 +130,11 @@ func (h *Handler) CronJobTask(ctx context.Context) {
 		return
 	}
 	log.Println("Successfully updated total counts for all stamps")
+
+	if err := h.repo.RefreshRankingSnapshots(ctx, time.Now()); err != nil {
+		log.Printf("Error refreshing ranking snapshots: %v", err)
+
+		return
+	}
+	log.Println("Successfully refreshed ranking snapshots")
 }
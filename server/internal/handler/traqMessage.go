@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	traqAPIBaseURL = "https://q.trap.jp/api/v3"
+	// traqChannelMaxDepth はチャンネルのパスを辿るときの親チャンネルの最大の深さ
+	traqChannelMaxDepth = 16
+)
+
+var (
+	// traqMessageURLPattern は traQ のメッセージのURL (https://q.trap.jp/messages/{id})
+	traqMessageURLPattern = regexp.MustCompile(`^https://q\.trap\.jp/messages/([0-9a-fA-F-]{36})/?$`)
+
+	errTraqNotFound = errors.New("not found on traQ")
+)
+
+type (
+	traqMessage struct {
+		ID        uuid.UUID          `json:"id"`
+		UserID    uuid.UUID          `json:"userId"`
+		ChannelID uuid.UUID          `json:"channelId"`
+		Content   string             `json:"content"`
+		CreatedAt time.Time          `json:"createdAt"`
+		Stamps    []traqMessageStamp `json:"stamps"`
+	}
+
+	traqMessageStamp struct {
+		StampID uuid.UUID `json:"stampId"`
+	}
+
+	traqChannel struct {
+		ID       uuid.UUID  `json:"id"`
+		ParentID *uuid.UUID `json:"parentId"`
+		Name     string     `json:"name"`
+	}
+)
+
+// parseTraqMessageURL はメッセージのURLからメッセージIDを取り出す
+func parseTraqMessageURL(messageURL string) (uuid.UUID, bool) {
+	m := traqMessageURLPattern.FindStringSubmatch(strings.TrimSpace(messageURL))
+	if m == nil {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(m[1])
+
+	return id, err == nil
+}
+
+// getTraq は traQ API の path を GET して out にデコードする。404 の場合は errTraqNotFound を返す
+func getTraq(ctx context.Context, botToken string, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, traqAPIBaseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Add("accept", "application/json")
+	req.Header.Add("Authorization", "Bearer "+botToken)
+
+	resp, err := traqHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errTraqNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("traQ API returned %d for %s", resp.StatusCode, path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func fetchTraqMessage(ctx context.Context, botToken string, messageID uuid.UUID) (*traqMessage, error) {
+	var message traqMessage
+	if err := getTraq(ctx, botToken, "/messages/"+messageID.String(), &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// fetchTraqChannelPath は公開チャンネルのパス (#a/b/c) を返す。
+// DM などの公開されていないチャンネルは traQ API で取得できないので errTraqNotFound になる
+func fetchTraqChannelPath(ctx context.Context, botToken string, channelID uuid.UUID) (string, error) {
+	names := []string{}
+	id := &channelID
+	for depth := 0; id != nil && depth < traqChannelMaxDepth; depth++ {
+		var channel traqChannel
+		if err := getTraq(ctx, botToken, "/channels/"+id.String(), &channel); err != nil {
+			return "", err
+		}
+		names = append([]string{channel.Name}, names...)
+		id = channel.ParentID
+	}
+
+	return "#" + strings.Join(names, "/"), nil
+}
+
+// fetchTraqUserName は traQ のユーザーの traQ ID を返す。キャッシュにない (Bot など) 場合は traQ API から取得する
+func (h *Handler) fetchTraqUserName(ctx context.Context, botToken string, userID uuid.UUID) (string, error) {
+	if name, ok := h.userCache.GetTraqID(userID); ok {
+		return name, nil
+	}
+	var user traqUser
+	if err := getTraq(ctx, botToken, "/users/"+userID.String(), &user); err != nil {
+		return "", err
+	}
+
+	return user.Name, nil
+}
+
+// usesStamp はメッセージの本文でスタンプが使われているか、スタンプでリアクションされているかを返す。
+// 本文のスタンプには :name.large: のようにエフェクトが付いていることがある
+func (m *traqMessage) usesStamp(stampID uuid.UUID, stampName string) bool {
+	for _, s := range m.Stamps {
+		if s.StampID == stampID {
+			return true
+		}
+	}
+	pattern := regexp.MustCompile(`:` + regexp.QuoteMeta(stampName) + `(\.[a-zA-Z0-9_\-]+)*:`)
+
+	return pattern.MatchString(m.Content)
+}
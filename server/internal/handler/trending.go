@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// trendingBaselineWeeks は比較対象とする過去の週数
+	trendingBaselineWeeks = 8
+	// trendingMinRecentCount は直近7日間でこれ未満しか使われていないスタンプを除外する
+	trendingMinRecentCount = 10
+	// trendingMinBaselineWeeks は z-score を計算するのに必要な比較対象の週数
+	trendingMinBaselineWeeks = 2
+	// risingMinRecentCount は新着スタンプを急上昇として扱う最小使用回数
+	risingMinRecentCount = 5
+	defaultTrendingLimit = 20
+	maxTrendingLimit     = 100
+)
+
+type trendingParams struct {
+	Limit    *int   `query:"limit"`
+	MinCount *int64 `query:"min_count"`
+}
+
+type trendingStampResponse struct {
+	StampID       uuid.UUID `json:"stamp_id"`
+	StampName     string    `json:"stamp_name"`
+	FileID        uuid.UUID `json:"file_id"`
+	Score         float64   `json:"score"`
+	RecentCount   int64     `json:"recent_count"`
+	BaselineMean  float64   `json:"baseline_mean"`
+	BaselineRatio float64   `json:"baseline_ratio"`
+}
+
+type trendingResponse struct {
+	Trending    []trendingStampResponse `json:"trending"`
+	RisingNew   []trendingStampResponse `json:"rising_new"`
+	WindowStart time.Time               `json:"window_start"`
+	WindowEnd   time.Time               `json:"window_end"`
+}
+
+// stampTrend は1スタンプ分の週ごとの使用回数 (weeks[0] が直近7日間)
+type stampTrend struct {
+	id        uuid.UUID
+	name      string
+	fileID    uuid.UUID
+	createdAt time.Time
+	weeks     [trendingBaselineWeeks + 1]int64
+}
+
+func (h *Handler) getTrending(c echo.Context) error {
+	var params trendingParams
+	if err := c.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid query parameters").SetInternal(err)
+	}
+	limit := defaultTrendingLimit
+	if params.Limit != nil {
+		if *params.Limit < 1 || *params.Limit > maxTrendingLimit {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be between 1 and 100")
+		}
+		limit = *params.Limit
+	}
+	minCount := int64(trendingMinRecentCount)
+	if params.MinCount != nil {
+		if *params.MinCount < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "min_count must be positive")
+		}
+		minCount = *params.MinCount
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	windowStart := today.AddDate(0, 0, -7)
+
+	usages, err := h.repo.GetWeeklyUsages(c.Request().Context(), today, trendingBaselineWeeks+1)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+	}
+
+	trends := make(map[uuid.UUID]*stampTrend)
+	for _, u := range usages {
+		if u.WeekIndex < 0 || u.WeekIndex > trendingBaselineWeeks {
+			continue
+		}
+		t, ok := trends[u.StampID]
+		if !ok {
+			t = &stampTrend{id: u.StampID, name: u.StampName, fileID: u.FileID, createdAt: u.CreatedAt}
+			trends[u.StampID] = t
+		}
+		t.weeks[u.WeekIndex] += u.Count
+	}
+
+	res := trendingResponse{
+		Trending:    []trendingStampResponse{},
+		RisingNew:   []trendingStampResponse{},
+		WindowStart: windowStart,
+		WindowEnd:   today,
+	}
+	for _, t := range trends {
+		recent := t.weeks[0]
+		if !t.createdAt.Before(windowStart) {
+			if recent >= risingMinRecentCount {
+				res.RisingNew = append(res.RisingNew, trendingStampResponse{
+					StampID:     t.id,
+					StampName:   t.name,
+					FileID:      t.fileID,
+					Score:       float64(recent),
+					RecentCount: recent,
+				})
+			}
+
+			continue
+		}
+		if recent < minCount {
+			continue
+		}
+		score, mean, ok := trendScore(t, windowStart)
+		if !ok || score <= 0 {
+			continue
+		}
+		res.Trending = append(res.Trending, trendingStampResponse{
+			StampID:       t.id,
+			StampName:     t.name,
+			FileID:        t.fileID,
+			Score:         score,
+			RecentCount:   recent,
+			BaselineMean:  mean,
+			BaselineRatio: float64(recent) / math.Max(mean, 1),
+		})
+	}
+
+	sortTrending(res.Trending)
+	sortTrending(res.RisingNew)
+	if len(res.Trending) > limit {
+		res.Trending = res.Trending[:limit]
+	}
+	if len(res.RisingNew) > limit {
+		res.RisingNew = res.RisingNew[:limit]
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// trendScore は直近7日間の使用回数を、作成後の過去の週の平均・標準偏差と比べた z-score を返す。
+// 比較できる週が少なすぎる場合は ok=false を返す。
+func trendScore(t *stampTrend, windowStart time.Time) (score float64, mean float64, ok bool) {
+	var baseline []float64
+	for i := 1; i <= trendingBaselineWeeks; i++ {
+		weekStart := windowStart.AddDate(0, 0, -7*i)
+		if t.createdAt.After(weekStart) {
+			break
+		}
+		baseline = append(baseline, float64(t.weeks[i]))
+	}
+	if len(baseline) < trendingMinBaselineWeeks {
+		return 0, 0, false
+	}
+
+	for _, v := range baseline {
+		mean += v
+	}
+	mean /= float64(len(baseline))
+	var variance float64
+	for _, v := range baseline {
+		variance += (v - mean) * (v - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(baseline)))
+
+	// 使用回数がほぼ一定のスタンプで z-score が発散しないよう、分母はポアソン分布の標準偏差で下支えする
+	denominator := math.Max(stddev, math.Sqrt(math.Max(mean, 1)))
+
+	return (float64(t.weeks[0]) - mean) / denominator, mean, true
+}
+
+func sortTrending(stamps []trendingStampResponse) {
+	sort.Slice(stamps, func(i, j int) bool {
+		if stamps[i].Score != stamps[j].Score {
+			return stamps[i].Score > stamps[j].Score
+		}
+
+		return stamps[i].StampName < stamps[j].StampName
+	})
+}
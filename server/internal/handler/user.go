@@ -16,6 +16,7 @@ func (h *Handler) GetUser(c echo.Context) error {
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
 	}
+	user.IsAdmin = h.isAdmin(creatorID)
 
 	return c.JSON(http.StatusOK, user)
 }
@@ -0,0 +1,257 @@
+// Package markup は説明文で使える Markdown のサブセットと traQ 形式のスタンプ参照 (:stamp_name:) を扱う。
+//
+// 対応している記法は段落・改行、箇条書き (- / *)、コードブロック (```)、インラインコード、
+// 太字 (**)、斜体 (*)、リンク ([text](https://...)) とスタンプ参照のみで、
+// 生の HTML はすべてエスケープされる。
+package markup
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Stamp は参照されたスタンプの描画に使う情報
+type Stamp struct {
+	ID     uuid.UUID
+	FileID uuid.UUID
+}
+
+var (
+	stampRefPattern = regexp.MustCompile(`:([a-zA-Z0-9_\-]{1,32}):`)
+	linkPattern     = regexp.MustCompile(`\[([^\[\]]+)\]\((https?://[^\s()]+)\)`)
+	// 太字の中には斜体 (*...*) を入れられる
+	boldPattern = regexp.MustCompile(`\*\*((?:[^*]|\*[^*\s][^*]*\*)+)\*\*`)
+	// 斜体は太字の後に置き換えるので、タグの入れ子が崩れないよう、置き換えたタグ (<) をまたがない
+	italicPattern = regexp.MustCompile(`\*([^*\s<][^*<]*)\*`)
+	urlPattern    = regexp.MustCompile(`https?://\S+`)
+)
+
+const codeFence = "```"
+
+type (
+	blockKind int
+
+	block struct {
+		kind  blockKind
+		lines []string
+	}
+)
+
+const (
+	blockParagraph blockKind = iota
+	blockList
+	blockCode
+)
+
+// parseBlocks は本文を段落・箇条書き・コードブロックに分ける
+func parseBlocks(src string) []block {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	blocks := []block{}
+	var current *block
+	flush := func() {
+		if current != nil {
+			blocks = append(blocks, *current)
+			current = nil
+		}
+	}
+	for _, line := range lines {
+		if current != nil && current.kind == blockCode {
+			if strings.TrimSpace(line) == codeFence {
+				flush()
+			} else {
+				current.lines = append(current.lines, line)
+			}
+
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, codeFence):
+			flush()
+			current = &block{kind: blockCode}
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			if current == nil || current.kind != blockList {
+				flush()
+				current = &block{kind: blockList}
+			}
+			current.lines = append(current.lines, strings.TrimSpace(trimmed[2:]))
+		default:
+			if current == nil || current.kind != blockParagraph {
+				flush()
+				current = &block{kind: blockParagraph}
+			}
+			current.lines = append(current.lines, trimmed)
+		}
+	}
+	flush()
+
+	return blocks
+}
+
+// splitCodeSpans はインラインのテキストをコードスパンとそれ以外に分ける。奇数番目の要素がコードスパンの中身
+func splitCodeSpans(text string) []string {
+	parts := strings.Split(text, "`")
+	if len(parts)%2 == 0 {
+		// 閉じられていないバッククォートは通常の文字として扱う
+		parts[len(parts)-2] += "`" + parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+
+	return parts
+}
+
+// ExtractStampNames は本文中のスタンプ参照のスタンプ名を出現順に重複なく返す。コードの中の参照は含めない
+func ExtractStampNames(src string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, b := range parseBlocks(src) {
+		if b.kind == blockCode {
+			continue
+		}
+		for _, line := range b.lines {
+			for i, part := range splitCodeSpans(line) {
+				if i%2 == 1 {
+					continue
+				}
+				for _, loc := range findStampRefs(part) {
+					name := part[loc[0]+1 : loc[1]-1]
+					if !seen[name] {
+						seen[name] = true
+						names = append(names, name)
+					}
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// Render は本文をサニタイズ済みの HTML に変換する。stamps に含まれないスタンプ参照はそのままのテキストで残す
+func Render(src string, stamps map[string]Stamp) string {
+	var sb strings.Builder
+	for _, b := range parseBlocks(src) {
+		switch b.kind {
+		case blockCode:
+			sb.WriteString("<pre><code>")
+			sb.WriteString(html.EscapeString(strings.Join(b.lines, "\n")))
+			sb.WriteString("</code></pre>")
+		case blockList:
+			sb.WriteString("<ul>")
+			for _, line := range b.lines {
+				sb.WriteString("<li>")
+				sb.WriteString(renderInline(line, stamps))
+				sb.WriteString("</li>")
+			}
+			sb.WriteString("</ul>")
+		case blockParagraph:
+			rendered := make([]string, len(b.lines))
+			for i, line := range b.lines {
+				rendered[i] = renderInline(line, stamps)
+			}
+			sb.WriteString("<p>")
+			sb.WriteString(strings.Join(rendered, "<br>"))
+			sb.WriteString("</p>")
+		}
+	}
+
+	return sb.String()
+}
+
+func renderInline(text string, stamps map[string]Stamp) string {
+	var sb strings.Builder
+	for i, part := range splitCodeSpans(text) {
+		if i%2 == 1 {
+			sb.WriteString("<code>")
+			sb.WriteString(html.EscapeString(part))
+			sb.WriteString("</code>")
+
+			continue
+		}
+		sb.WriteString(renderText(part, stamps))
+	}
+
+	return sb.String()
+}
+
+// renderText はコードスパン以外のテキストを描画する。リンクのテキストにも装飾とスタンプ参照を適用する
+func renderText(text string, stamps map[string]Stamp) string {
+	var sb strings.Builder
+	last := 0
+	for _, m := range linkPattern.FindAllStringSubmatchIndex(text, -1) {
+		sb.WriteString(renderEmphasis(text[last:m[0]], stamps))
+		label := text[m[2]:m[3]]
+		href := text[m[4]:m[5]]
+		sb.WriteString(`<a href="`)
+		sb.WriteString(html.EscapeString(href))
+		sb.WriteString(`" rel="noopener noreferrer nofollow" target="_blank">`)
+		sb.WriteString(renderEmphasis(label, stamps))
+		sb.WriteString("</a>")
+		last = m[1]
+	}
+	sb.WriteString(renderEmphasis(text[last:], stamps))
+
+	return sb.String()
+}
+
+func renderEmphasis(text string, stamps map[string]Stamp) string {
+	escaped := html.EscapeString(text)
+	escaped = boldPattern.ReplaceAllString(escaped, "<strong>$1</strong>")
+	escaped = italicPattern.ReplaceAllString(escaped, "<em>$1</em>")
+
+	// スタンプ名に使える文字はエスケープの影響を受けないので、エスケープ後の文字列から置き換えてよい
+	var sb strings.Builder
+	last := 0
+	for _, loc := range findStampRefs(escaped) {
+		ref := escaped[loc[0]:loc[1]]
+		stamp, ok := stamps[ref[1:len(ref)-1]]
+		if !ok {
+			continue
+		}
+		sb.WriteString(escaped[last:loc[0]])
+		sb.WriteString(`<span class="stamp" data-stamp-id="` + stamp.ID.String() + `" data-file-id="` + stamp.FileID.String() +
+			`" title="` + ref + `">` + ref + `</span>`)
+		last = loc[1]
+	}
+	sb.WriteString(escaped[last:])
+
+	return sb.String()
+}
+
+// findStampRefs はスタンプ参照の位置を返す。"10:30:00" のように前後が英数字に続いているものや、
+// リンク先などの URL の中にあるものは参照とみなさない
+func findStampRefs(text string) [][]int {
+	urls := urlPattern.FindAllStringIndex(text, -1)
+	locs := [][]int{}
+	for _, loc := range stampRefPattern.FindAllStringIndex(text, -1) {
+		if loc[0] > 0 && isStampNameChar(text[loc[0]-1]) || loc[1] < len(text) && isStampNameChar(text[loc[1]]) {
+			continue
+		}
+		if inRanges(loc, urls) {
+			continue
+		}
+		locs = append(locs, loc)
+	}
+
+	return locs
+}
+
+// inRanges は loc が ranges のいずれかと重なっているかを返す
+func inRanges(loc []int, ranges [][]int) bool {
+	for _, r := range ranges {
+		if loc[0] < r[1] && r[0] < loc[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isStampNameChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_' || c == '-'
+}
@@ -0,0 +1,121 @@
+package markup
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var testStamps = map[string]Stamp{
+	"ok": {
+		ID:     uuid.MustParse("00000000-0000-0000-0000-000000000001"),
+		FileID: uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+	},
+}
+
+const okStampHTML = `<span class="stamp" data-stamp-id="00000000-0000-0000-0000-000000000001" data-file-id="00000000-0000-0000-0000-000000000002" title=":ok:">:ok:</span>`
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"script tag", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"img onerror", "<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>"},
+		{"script in code block", "```\n<script>\n:ok:\n```", "<pre><code>&lt;script&gt;\n:ok:</code></pre>"},
+		{"html in list", "- <i>a</i>\n- :ok:", "<ul><li>&lt;i&gt;a&lt;/i&gt;</li><li>" + okStampHTML + "</li></ul>"},
+		{
+			"attribute injection in link text",
+			`[x" onclick="alert(1)](https://example.com)`,
+			`<p><a href="https://example.com" rel="noopener noreferrer nofollow" target="_blank">x&#34; onclick=&#34;alert(1)</a></p>`,
+		},
+		{
+			"attribute injection in href",
+			`[x](https://example.com/" onmouseover="alert(1))`,
+			`<p>[x](https://example.com/&#34; onmouseover=&#34;alert(1))</p>`,
+		},
+		{
+			"quote in href",
+			`[x](https://example.com/"onmouseover="alert)`,
+			`<p><a href="https://example.com/&#34;onmouseover=&#34;alert" rel="noopener noreferrer nofollow" target="_blank">x</a></p>`,
+		},
+		{"javascript href", "[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>"},
+		{"mixed case javascript href", "[x](JaVaScRiPt:alert(1))", "<p>[x](JaVaScRiPt:alert(1))</p>"},
+		{"data href", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>[x](data:text/html;base64,PHNjcmlwdD4=)</p>"},
+		{"mixed case http href", "[x](HTTPS://example.com)", "<p>[x](HTTPS://example.com)</p>"},
+		{"bold", "**a** and **b**", "<p><strong>a</strong> and <strong>b</strong></p>"},
+		{"italic in bold", "**bold *it* x**", "<p><strong>bold <em>it</em> x</strong></p>"},
+		{"bold italic", "***x***", "<p><strong><em>x</em></strong></p>"},
+		{"italic across bold", "*a **b *c* d** e*", "<p>*a <strong>b <em>c</em> d</strong> e*</p>"},
+		{"unterminated bold", "**unterminated", "<p>**unterminated</p>"},
+		{"unterminated italic", "*unterminated", "<p>*unterminated</p>"},
+		{"markup in code span", "`<b>**x** :ok:</b>`", "<p><code>&lt;b&gt;**x** :ok:&lt;/b&gt;</code></p>"},
+		{"unterminated code span", "`unterminated <b>", "<p>`unterminated &lt;b&gt;</p>"},
+		{"stamp ref", "a :ok: b", "<p>a " + okStampHTML + " b</p>"},
+		{"unknown stamp ref", ":unknown:", "<p>:unknown:</p>"},
+		{"time is not a stamp ref", "10:30:00", "<p>10:30:00</p>"},
+		{
+			"stamp ref in link",
+			"[:ok:](https://example.com/:ok:)",
+			`<p><a href="https://example.com/:ok:" rel="noopener noreferrer nofollow" target="_blank">` + okStampHTML + `</a></p>`,
+		},
+		{"stamp ref in bare url", "https://example.com/:ok: :ok:", "<p>https://example.com/:ok: " + okStampHTML + "</p>"},
+		{"paragraphs", "a\nb\n\nc", "<p>a<br>b</p><p>c</p>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Render(tt.src, testStamps); got != tt.want {
+				t.Errorf("Render(%q)\n got  %q\n want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderDoesNotEmitUnsafeMarkup(t *testing.T) {
+	payloads := []string{
+		"<script>alert(1)</script>",
+		"<svg/onload=alert(1)>",
+		`"><img src=x onerror=alert(1)>`,
+		"[<script>](https://example.com)",
+		"[x](https://example.com)<script>",
+		"**<script>**",
+		"*<script>*",
+		"`</code><script>`",
+		"```\n</code></pre><script>\n```",
+	}
+	for _, src := range payloads {
+		got := Render(src, testStamps)
+		for _, bad := range []string{"<script", "<img", "<svg"} {
+			if strings.Contains(got, bad) {
+				t.Errorf("Render(%q) = %q contains %q", src, got, bad)
+			}
+		}
+	}
+}
+
+func TestExtractStampNames(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{"none", "plain text", []string{}},
+		{"dedup in order", ":b: :a: :b:", []string{"b", "a"}},
+		{"code span", "`:a:` :b:", []string{"b"}},
+		{"code block", "```\n:a:\n```\n:b:", []string{"b"}},
+		{"link target", "[text](https://example.com/:a:)", []string{}},
+		{"link label", "[:a:](https://example.com/:b:)", []string{"a"}},
+		{"bare url", "https://example.com/:a:/x :b:", []string{"b"}},
+		{"time", "10:30:00", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractStampNames(tt.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractStampNames(%q) = %q, want %q", tt.src, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,19 +2,49 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
-type TagInfo struct {
-	Name string
-}
+// BulkConflictPolicy は一括登録で、既にあるものと内容が食い違う項目の扱い
+type BulkConflictPolicy string
+
+const (
+	// BulkConflictSkip は食い違う項目を飛ばし、ほかの項目を登録する
+	BulkConflictSkip BulkConflictPolicy = "skip"
+	// BulkConflictOverwrite は食い違う項目を上書きする
+	BulkConflictOverwrite BulkConflictPolicy = "overwrite"
+	// BulkConflictError は食い違う項目や登録できない項目が1つでもあれば何も登録しない
+	BulkConflictError BulkConflictPolicy = "error"
+)
+
+// 一括登録の項目の種類
+const (
+	BulkTargetTag         = "tag"
+	BulkTargetStampTag    = "stamp_tag"
+	BulkTargetDescription = "description"
+)
+
+// 一括登録の項目ごとの結果
+const (
+	BulkStatusCreated  = "created"
+	BulkStatusExisting = "existing"
+	BulkStatusUpdated  = "updated"
+	BulkStatusSkipped  = "skipped"
+	BulkStatusError    = "error"
+)
+
+// bulkCreatorID は一括登録したタグ・タグ付け・説明文の作成者。説明文はオフラインで生成したものなので自動生成と同じユーザーにする
+var bulkCreatorID = MachineDescriptionCreatorID
+
+var ErrBulkConflict = errors.New("bulk import has conflicting or invalid items")
 
-type CreatedTagInfo struct {
-	ID   uuid.UUID
+type TagInfo struct {
 	Name string
 }
 
@@ -41,13 +71,69 @@ type stampDescriptionData struct {
 	StampID     uuid.UUID `db:"stamp_id" json:"stamp_id"`
 	Description string    `db:"description" json:"description"`
 	CreatorID   uuid.UUID `db:"creator_id" json:"creator_id"`
+	Lang        string    `db:"lang" json:"lang"`
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
 }
 
-func (r *Repository) BulkCreateTags(ctx context.Context, tags []TagInfo) ([]CreatedTagInfo, error) {
+type (
+	BulkOptions struct {
+		OnConflict BulkConflictPolicy
+		// DryRun が true のとき、結果だけを返して何も書き込まない
+		DryRun bool
+	}
+
+	BulkItemResult struct {
+		// Index はリクエストの配列での位置
+		Index   int        `json:"index"`
+		Target  string     `json:"target"`
+		StampID *uuid.UUID `json:"stamp_id,omitempty"`
+		TagID   *uuid.UUID `json:"tag_id,omitempty"`
+		TagName string     `json:"tag_name,omitempty"`
+		Status  string     `json:"status"`
+		// Reason は飛ばした・失敗した理由
+		Reason string `json:"reason,omitempty"`
+	}
+)
+
+// conflictStatus は既にあるものと食い違う項目の結果を返す
+func (o BulkOptions) conflictStatus() string {
+	switch o.OnConflict {
+	case BulkConflictOverwrite:
+		return BulkStatusUpdated
+	case BulkConflictError:
+		return BulkStatusError
+	default:
+		return BulkStatusSkipped
+	}
+}
+
+// finishBulk は一括登録のトランザクションを確定し、確定したかを返す。
+// DryRun のときと、on_conflict=error で失敗した項目があるときは確定しない (後者は ErrBulkConflict を返す)
+func finishBulk(tx *sqlx.Tx, results []BulkItemResult, opts BulkOptions) (bool, error) {
+	if opts.OnConflict == BulkConflictError {
+		for _, result := range results {
+			if result.Status == BulkStatusError {
+				return false, ErrBulkConflict
+			}
+		}
+	}
+	if opts.DryRun {
+		return false, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// BulkCreateTags はタグを一括で作る。既にある名前や別名はそのタグを existing として返すので、同じ内容で何度実行してもよい。
+// タグは名前しか持たないので、既にあるタグと食い違うことはない
+func (r *Repository) BulkCreateTags(ctx context.Context, tags []TagInfo, opts BulkOptions) ([]BulkItemResult, error) {
+	results := make([]BulkItemResult, 0, len(tags))
 	if len(tags) == 0 {
-		return []CreatedTagInfo{}, nil
+		return results, nil
 	}
 
 	tx, err := r.db.BeginTxx(ctx, nil)
@@ -56,83 +142,237 @@ func (r *Repository) BulkCreateTags(ctx context.Context, tags []TagInfo) ([]Crea
 	}
 	defer tx.Rollback()
 
-	creatorID, err := uuid.Parse("3b261ff3-f940-4e2c-a626-27387b6dd71b")
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	existingTags := map[string]uuid.UUID{}
+	query, args, err := sqlx.In("SELECT id AS tag_id, name FROM tags WHERE name IN (?) FOR UPDATE", names)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse creatorID: %w", err)
+		return nil, fmt.Errorf("failed to build tags query: %w", err)
+	}
+	entries := []TagNameEntry{}
+	if err := tx.SelectContext(ctx, &entries, tx.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to select tags: %w", err)
+	}
+	for _, e := range entries {
+		existingTags[e.Name] = e.TagID
+	}
+	aliases := map[string]uuid.UUID{}
+	query, args, err = sqlx.In("SELECT name, tag_id FROM tag_aliases WHERE name IN (?)", names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tag_aliases query: %w", err)
+	}
+	entries = []TagNameEntry{}
+	if err := tx.SelectContext(ctx, &entries, tx.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to select tag_aliases: %w", err)
+	}
+	for _, e := range entries {
+		aliases[e.Name] = e.TagID
 	}
-	now := time.Now()
-
-	tagsToInsert := make([]tagInsertData, len(tags))
-	createdTags := make([]CreatedTagInfo, len(tags))
 
+	now := time.Now()
+	var tagsToInsert []tagInsertData
 	for i, tag := range tags {
-		tagID := uuid.New()
-		tagsToInsert[i] = tagInsertData{
-			ID:        tagID,
-			Name:      tag.Name,
-			CreatorID: creatorID,
-			CreatedAt: now,
-			UpdatedAt: now,
-		}
-		createdTags[i] = CreatedTagInfo{
-			ID:   tagID,
-			Name: tag.Name,
+		result := BulkItemResult{Index: i, Target: BulkTargetTag, TagName: tag.Name}
+		if tagID, ok := existingTags[tag.Name]; ok {
+			result.TagID, result.Status = &tagID, BulkStatusExisting
+		} else if tagID, ok := aliases[tag.Name]; ok {
+			// 統合されたタグの旧名は統合先のタグとして扱う
+			result.TagID, result.Status, result.Reason = &tagID, BulkStatusExisting, "name is an alias of another tag"
+		} else {
+			tagID, _ := uuid.NewV7()
+			tagsToInsert = append(tagsToInsert, tagInsertData{
+				ID:        tagID,
+				Name:      tag.Name,
+				CreatorID: bulkCreatorID,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+			existingTags[tag.Name] = tagID
+			result.TagID, result.Status = &tagID, BulkStatusCreated
 		}
+		results = append(results, result)
 	}
 
-	_, err = tx.NamedExecContext(ctx, `
-		INSERT INTO tags (id, name, creator_id, created_at, updated_at)
-		VALUES (:id, :name, :creator_id, :created_at, :updated_at)
-	`, tagsToInsert)
-	if err != nil {
-		return nil, fmt.Errorf("failed to bulk insert tags: %w", err)
+	if len(tagsToInsert) > 0 {
+		_, err = tx.NamedExecContext(ctx, `
+			INSERT INTO tags (id, name, creator_id, created_at, updated_at)
+			VALUES (:id, :name, :creator_id, :created_at, :updated_at)
+		`, tagsToInsert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bulk insert tags: %w", err)
+		}
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	committed, err := finishBulk(tx, results, opts)
+	if committed {
+		r.invalidateTagGraph()
 	}
 
-	return createdTags, nil
+	return results, err
 }
 
-func (r *Repository) BulkAddStampMeta(ctx context.Context, additions []StampMetaAddition) error {
+// BulkAddStampMeta はスタンプへのタグ付けと説明文を一括で登録する。
+// 既にあるタグ付けや同じ本文の説明文は existing になり、本文の食い違う説明文は opts.OnConflict に従って扱う
+func (r *Repository) BulkAddStampMeta(ctx context.Context, additions []StampMetaAddition, opts BulkOptions) ([]BulkItemResult, error) {
+	results := []BulkItemResult{}
 	if len(additions) == 0 {
-		return nil
+		return results, nil
 	}
 
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	creatorID, err := uuid.Parse("3b261ff3-f940-4e2c-a626-27387b6dd71b")
+	var stampIDs, tagIDs []uuid.UUID
+	for _, addition := range additions {
+		stampIDs = append(stampIDs, addition.StampID)
+		tagIDs = append(tagIDs, addition.TagIDs...)
+	}
+
+	existingStamps := map[uuid.UUID]bool{}
+	query, args, err := sqlx.In("SELECT id FROM stamps WHERE id IN (?)", stampIDs)
 	if err != nil {
-		return fmt.Errorf("failed to parse creatorID: %w", err)
+		return nil, fmt.Errorf("failed to build stamps query: %w", err)
+	}
+	ids := []uuid.UUID{}
+	if err := tx.SelectContext(ctx, &ids, tx.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to select stamps: %w", err)
+	}
+	for _, id := range ids {
+		existingStamps[id] = true
 	}
-	now := time.Now()
 
-	var linksToInsert []stampTagLinkData
-	var descriptionsToInsert []stampDescriptionData
+	existingTags := map[uuid.UUID]bool{}
+	if len(tagIDs) > 0 {
+		query, args, err = sqlx.In("SELECT id FROM tags WHERE id IN (?)", tagIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build tags query: %w", err)
+		}
+		ids = []uuid.UUID{}
+		if err := tx.SelectContext(ctx, &ids, tx.Rebind(query), args...); err != nil {
+			return nil, fmt.Errorf("failed to select tags: %w", err)
+		}
+		for _, id := range ids {
+			existingTags[id] = true
+		}
+	}
 
-	for _, addition := range additions {
+	type stampTagKey struct{ stampID, tagID uuid.UUID }
+	links := map[stampTagKey]bool{}
+	query, args, err = sqlx.In("SELECT stamp_id, tag_id, creator_id FROM stamp_tags WHERE stamp_id IN (?) FOR UPDATE", stampIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build stamp_tags query: %w", err)
+	}
+	linked := []stampTagLinkData{}
+	if err := tx.SelectContext(ctx, &linked, tx.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to select stamp_tags: %w", err)
+	}
+	for _, l := range linked {
+		links[stampTagKey{l.StampID, l.TagID}] = true
+	}
+
+	descriptions := map[uuid.UUID]string{}
+	query, args, err = sqlx.In("SELECT stamp_id, description, creator_id, lang, created_at, updated_at FROM stamp_descriptions WHERE creator_id = ? AND lang = ? AND stamp_id IN (?) FOR UPDATE",
+		bulkCreatorID, DefaultDescriptionLang, stampIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build stamp_descriptions query: %w", err)
+	}
+	existingDescriptions := []stampDescriptionData{}
+	if err := tx.SelectContext(ctx, &existingDescriptions, tx.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to select stamp_descriptions: %w", err)
+	}
+	for _, d := range existingDescriptions {
+		descriptions[d.StampID] = d.Description
+	}
+
+	now := time.Now()
+	var linksToInsert []stampTagLinkData
+	created, updated := 0, 0
+	for i, addition := range additions {
 		for _, tagID := range addition.TagIDs {
-			linksToInsert = append(linksToInsert, stampTagLinkData{
-				StampID:   addition.StampID,
-				TagID:     tagID,
-				CreatorID: creatorID,
-			})
+			result := BulkItemResult{Index: i, Target: BulkTargetStampTag, StampID: &addition.StampID, TagID: &tagID}
+			key := stampTagKey{addition.StampID, tagID}
+			switch {
+			case !existingStamps[addition.StampID]:
+				result.Status, result.Reason = BulkStatusError, "stamp not found"
+			case !existingTags[tagID]:
+				result.Status, result.Reason = BulkStatusError, "tag not found"
+			case links[key]:
+				result.Status = BulkStatusExisting
+			default:
+				result.Status = BulkStatusCreated
+				links[key] = true
+				linksToInsert = append(linksToInsert, stampTagLinkData{
+					StampID:   addition.StampID,
+					TagID:     tagID,
+					CreatorID: bulkCreatorID,
+				})
+			}
+			results = append(results, result)
 		}
 
-		if addition.Description != "" {
-			descriptionsToInsert = append(descriptionsToInsert, stampDescriptionData{
+		if addition.Description == "" {
+			continue
+		}
+		result := BulkItemResult{Index: i, Target: BulkTargetDescription, StampID: &addition.StampID}
+		current, exists := descriptions[addition.StampID]
+		switch {
+		case !existingStamps[addition.StampID]:
+			result.Status, result.Reason = BulkStatusError, "stamp not found"
+		case !exists:
+			result.Status = BulkStatusCreated
+			d := stampDescriptionData{
 				StampID:     addition.StampID,
 				Description: addition.Description,
-				CreatorID:   creatorID,
+				CreatorID:   bulkCreatorID,
+				Lang:        DefaultDescriptionLang,
 				CreatedAt:   now,
 				UpdatedAt:   now,
-			})
+			}
+			if _, err := tx.NamedExecContext(ctx, `
+				INSERT INTO stamp_descriptions (stamp_id, description, creator_id, lang, source, created_at, updated_at)
+				VALUES (:stamp_id, :description, :creator_id, :lang, '`+DescriptionSourceMachine+`', :created_at, :updated_at)
+			`, d); err != nil {
+				return nil, fmt.Errorf("failed to insert stamp_description: %w", err)
+			}
+			if err := insertDescriptionRevision(ctx, tx, d.StampID, d.CreatorID, d.Lang, d.CreatorID, DescriptionActionCreate, &d.Description, DescriptionFields{}); err != nil {
+				return nil, err
+			}
+			created++
+		case current == addition.Description:
+			result.Status = BulkStatusExisting
+		default:
+			result.Status = opts.conflictStatus()
+			if result.Status != BulkStatusUpdated {
+				result.Reason = "a different description already exists"
+
+				break
+			}
+			// 本文を差し替えるので、生成に使ったモデルなどの情報は消す
+			if _, err := tx.ExecContext(ctx, "UPDATE stamp_descriptions SET description = ?, model = NULL, confidence = NULL, updated_at = ? WHERE stamp_id = ? AND creator_id = ? AND lang = ?",
+				addition.Description, now, addition.StampID, bulkCreatorID, DefaultDescriptionLang); err != nil {
+				return nil, fmt.Errorf("failed to update stamp_description: %w", err)
+			}
+			fields, err := selectDescriptionFields(ctx, tx, addition.StampID, bulkCreatorID, DefaultDescriptionLang)
+			if err != nil {
+				return nil, err
+			}
+			if err := insertDescriptionRevision(ctx, tx, addition.StampID, bulkCreatorID, DefaultDescriptionLang, bulkCreatorID, DescriptionActionUpdate, &addition.Description, fields); err != nil {
+				return nil, err
+			}
+			updated++
 		}
+		if result.Status == BulkStatusCreated || result.Status == BulkStatusUpdated {
+			descriptions[addition.StampID] = addition.Description
+			if err := replaceDescriptionReferences(ctx, tx, addition.StampID, bulkCreatorID, DefaultDescriptionLang, addition.Description); err != nil {
+				return nil, err
+			}
+		}
+		results = append(results, result)
 	}
 
 	if len(linksToInsert) > 0 {
@@ -142,24 +382,17 @@ func (r *Repository) BulkAddStampMeta(ctx context.Context, additions []StampMeta
 			VALUES (:stamp_id, :tag_id, :creator_id)
 		`, linksToInsert)
 		if err != nil {
-			return fmt.Errorf("failed to bulk insert stamp_tags: %w", err)
+			return nil, fmt.Errorf("failed to bulk insert stamp_tags: %w", err)
 		}
 	}
+	if created > 0 || updated > 0 {
+		log.Printf("Created %d and updated %d stamp descriptions", created, updated)
+	}
 
-	if len(descriptionsToInsert) > 0 {
-		log.Printf("Inserting %d stamp descriptions", len(descriptionsToInsert))
-		_, err = tx.NamedExecContext(ctx, `
-			INSERT INTO stamp_descriptions (stamp_id, description, creator_id, created_at, updated_at)
-			VALUES (:stamp_id, :description, :creator_id, :created_at, :updated_at)
-		`, descriptionsToInsert)
-		if err != nil {
-			log.Printf("Failed to insert stamp_descriptions. The data was: %+v", descriptionsToInsert)
-
-			log.Printf("Error inserting descriptions: %v", err)
-
-			return fmt.Errorf("failed to bulk insert/update stamp_descriptions: %w", err)
-		}
+	committed, err := finishBulk(tx, results, opts)
+	if committed {
+		r.invalidateTagGraph()
 	}
 
-	return tx.Commit()
+	return results, err
 }
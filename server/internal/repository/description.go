@@ -9,53 +9,139 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultDescriptionLang は言語を指定しなかった説明文の言語
+const DefaultDescriptionLang = "ja"
+
 type (
 	CreateDescriptionParams struct {
 		StampID     uuid.UUID `db:"stamp_id"`
 		Description string    `db:"description"`
 		CreatorID   uuid.UUID `db:"creator_id"`
+		Lang        string    `db:"lang"`
+		DescriptionFields
 	}
 	StampDescription struct {
-		Description string    `db:"description" json:"description"`
-		CreatorID   uuid.UUID `db:"creator_id" json:"creator_id"`
-		CreatedAt   time.Time `db:"created_at" json:"created_at"`
-		UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+		Description string `db:"description" json:"description"`
+		DescriptionFields
+		DescriptionProvenance
+		CreatorID uuid.UUID `db:"creator_id" json:"creator_id"`
+		Lang      string    `db:"lang" json:"lang"`
+		CreatedAt time.Time `db:"created_at" json:"created_at"`
+		UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+		Upvotes   int       `db:"upvotes" json:"upvotes"`
+		Downvotes int       `db:"downvotes" json:"downvotes"`
+		// Score は投票のウィルソンスコアで、説明文はこの降順に並ぶ
+		Score float64 `db:"-" json:"score"`
+		// DescriptionHTML は Description をサニタイズして描画した HTML
+		DescriptionHTML string `db:"-" json:"description_html"`
 	}
 )
 
 func (r *Repository) CreateDescriptions(ctx context.Context, params CreateDescriptionParams) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	now := time.Now()
-	if _, err := r.db.ExecContext(ctx, "INSERT INTO stamp_descriptions(stamp_id,description,creator_id,created_at,updated_at) VALUES(?,?,?,?,?)", params.StampID, params.Description, params.CreatorID, now, now); err != nil {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO stamp_descriptions (stamp_id, description, meaning, usage_notes, origin, examples, creator_id, lang, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		params.StampID, params.Description, params.Meaning, params.Usage, params.Origin, params.Examples, params.CreatorID, params.Lang, now, now); err != nil {
+		if isDuplicateEntry(err) {
+			return ErrDescriptionAlreadyExists
+		}
+
 		return fmt.Errorf("failed to insert description: %w", err)
 	}
+	if err := insertDescriptionRevision(ctx, tx, params.StampID, params.CreatorID, params.Lang, params.CreatorID, DescriptionActionCreate, &params.Description, params.DescriptionFields); err != nil {
+		return err
+	}
+	if err := replaceDescriptionReferences(ctx, tx, params.StampID, params.CreatorID, params.Lang, params.Description); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit()
 }
 
+// GetDescriptionsByStampID はスタンプのすべての言語の説明文を返す
 func (r *Repository) GetDescriptionsByStampID(ctx context.Context, stampID uuid.UUID) ([]*StampDescription, error) {
 	descriptions := []*StampDescription{}
-	if err := r.db.SelectContext(ctx, &descriptions, "SELECT description,creator_id,created_at,updated_at FROM stamp_descriptions WHERE stamp_id = ?", stampID); err != nil {
+	if err := r.db.SelectContext(ctx, &descriptions, `
+		SELECT
+			d.description, d.meaning, d.usage_notes, d.origin, d.examples, d.source, d.model, d.confidence,
+			d.creator_id, d.lang, d.created_at, d.updated_at,
+			COUNT(CASE WHEN v.value > 0 THEN 1 END) AS upvotes,
+			COUNT(CASE WHEN v.value < 0 THEN 1 END) AS downvotes
+		FROM stamp_descriptions d
+		LEFT JOIN description_votes v ON v.stamp_id = d.stamp_id AND v.creator_id = d.creator_id AND v.lang = d.lang
+		WHERE d.stamp_id = ? AND d.hidden = FALSE
+		GROUP BY d.stamp_id, d.creator_id, d.lang`, stampID); err != nil {
 		return nil, fmt.Errorf("failed to get descriptions by stampID: %w", err)
 	}
+	sortDescriptionsByScore(descriptions)
 
 	return descriptions, nil
 }
 
-func (r *Repository) DeleteDescriptions(ctx context.Context, stampID uuid.UUID, creatorID uuid.UUID) error {
-	if _, err := r.db.ExecContext(ctx, "DELETE FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ?", stampID, creatorID); err != nil {
+func (r *Repository) DeleteDescriptions(ctx context.Context, stampID uuid.UUID, creatorID uuid.UUID, lang string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockDescription(ctx, tx, stampID, creatorID, lang); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ? AND lang = ?", stampID, creatorID, lang); err != nil {
 		return fmt.Errorf("failed to delete description: %w", err)
 	}
+	if err := insertDescriptionRevision(ctx, tx, stampID, creatorID, lang, creatorID, DescriptionActionDelete, nil, DescriptionFields{}); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (r *Repository) UpdateDescriptions(ctx context.Context, stampID uuid.UUID, creatorID uuid.UUID, description string) error {
+// UpdateDescriptions は説明文を更新する。構造化された項目は fields で指定したものだけを変更する
+func (r *Repository) UpdateDescriptions(ctx context.Context, stampID uuid.UUID, creatorID uuid.UUID, lang string, description string, fields DescriptionFieldsUpdate) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockDescription(ctx, tx, stampID, creatorID, lang); err != nil {
+		return err
+	}
 	now := time.Now()
-	if _, err := r.db.ExecContext(ctx, "UPDATE stamp_descriptions SET description = ?,updated_at = ? WHERE stamp_id = ? AND creator_id =?", description, now, stampID, creatorID); err != nil {
+	// 項目が nil なら COALESCE で元の値のまま、空文字列なら NULLIF で未設定に戻す
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE stamp_descriptions SET
+			description = ?,
+			meaning = NULLIF(COALESCE(?, meaning), ''),
+			usage_notes = NULLIF(COALESCE(?, usage_notes), ''),
+			origin = NULLIF(COALESCE(?, origin), ''),
+			examples = IF(?, ?, examples),
+			updated_at = ?
+		WHERE stamp_id = ? AND creator_id = ? AND lang = ?`,
+		description, fields.Meaning, fields.Usage, fields.Origin, fields.Examples != nil, examplesValue(fields.Examples), now, stampID, creatorID, lang); err != nil {
 		return fmt.Errorf("failed to update description: %w", err)
 	}
+	updated, err := selectDescriptionFields(ctx, tx, stampID, creatorID, lang)
+	if err != nil {
+		return err
+	}
+	if err := insertDescriptionRevision(ctx, tx, stampID, creatorID, lang, creatorID, DescriptionActionUpdate, &description, updated); err != nil {
+		return err
+	}
+	if err := replaceDescriptionReferences(ctx, tx, stampID, creatorID, lang, description); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit()
 }
 
 var (
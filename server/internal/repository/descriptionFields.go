@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// DescriptionExamples は説明文の例文。DB には1行に1つずつ改行区切りで保存する
+type DescriptionExamples []string
+
+func (e *DescriptionExamples) Scan(src any) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		*e = DescriptionExamples{}
+
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("unsupported type for description examples: %T", src)
+	}
+	if text == "" {
+		*e = DescriptionExamples{}
+
+		return nil
+	}
+	*e = strings.Split(text, "\n")
+
+	return nil
+}
+
+func (e DescriptionExamples) Value() (driver.Value, error) {
+	if len(e) == 0 {
+		return nil, nil
+	}
+
+	return strings.Join(e, "\n"), nil
+}
+
+// DescriptionFields は説明文の構造化された項目。いずれも任意で、未設定なら nil (例文は空)
+type DescriptionFields struct {
+	Meaning  *string             `db:"meaning" json:"meaning"`
+	Usage    *string             `db:"usage_notes" json:"usage"`
+	Origin   *string             `db:"origin" json:"origin"`
+	Examples DescriptionExamples `db:"examples" json:"examples"`
+}
+
+// DescriptionFieldsUpdate は説明文の構造化された項目の更新内容。nil の項目は変更せず、空文字列や空の例文は未設定に戻す
+type DescriptionFieldsUpdate struct {
+	Meaning  *string
+	Usage    *string
+	Origin   *string
+	Examples *DescriptionExamples
+}
+
+// examplesValue は更新する例文を DB に渡す値にする。nil (変更しない) の場合も nil を返す
+func examplesValue(examples *DescriptionExamples) DescriptionExamples {
+	if examples == nil {
+		return nil
+	}
+
+	return *examples
+}
+
+// selectDescriptionFields は説明文の構造化された項目を返す
+func selectDescriptionFields(ctx context.Context, tx *sqlx.Tx, stampID uuid.UUID, creatorID uuid.UUID, lang string) (DescriptionFields, error) {
+	var fields DescriptionFields
+	if err := tx.GetContext(ctx, &fields, "SELECT meaning, usage_notes, origin, examples FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ? AND lang = ?", stampID, creatorID, lang); err != nil {
+		return DescriptionFields{}, fmt.Errorf("select description fields: %w", err)
+	}
+
+	return fields, nil
+}
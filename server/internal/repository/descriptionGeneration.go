@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DescriptionSourceHuman   = "human"
+	DescriptionSourceMachine = "machine"
+)
+
+// MachineDescriptionCreatorID は自動で生成した説明文の作成者として記録するID
+var MachineDescriptionCreatorID = uuid.MustParse("3b261ff3-f940-4e2c-a626-27387b6dd71b")
+
+type (
+	// DescriptionProvenance は説明文の出所。生成した説明文 (source が machine) ではモデル名と確信度 (0〜1) が入ることがある
+	DescriptionProvenance struct {
+		Source     string   `db:"source" json:"source"`
+		Model      *string  `db:"model" json:"model"`
+		Confidence *float64 `db:"confidence" json:"confidence"`
+	}
+
+	// DescriptionGenerationTarget は説明文を生成するスタンプ
+	DescriptionGenerationTarget struct {
+		ID     uuid.UUID `db:"id"`
+		Name   string    `db:"name"`
+		FileID uuid.UUID `db:"file_id"`
+	}
+
+	MachineDescription struct {
+		StampID uuid.UUID
+		// FileID は生成に使ったスタンプ画像の file_id
+		FileID      uuid.UUID
+		Description string
+		Model       string
+		Confidence  float64
+	}
+)
+
+// descriptionGenerationMaxBackoffDays は生成に失敗し続けるスタンプを生成し直すまでの最長の日数
+const descriptionGenerationMaxBackoffDays = 30
+
+// GetDescriptionGenerationQueue は説明文を生成するべきスタンプを、よく使われている順に最大 limit 件返す。
+// 説明文が1つもないスタンプと、生成した説明文があるがその後に画像が変わったスタンプが対象。
+// 同じ画像で生成に失敗したスタンプは、失敗の回数に応じて 1, 2, 4, ... 日 (最長30日) 空けるまで対象にしない
+func (r *Repository) GetDescriptionGenerationQueue(ctx context.Context, limit int) ([]*DescriptionGenerationTarget, error) {
+	targets := []*DescriptionGenerationTarget{}
+	if err := r.db.SelectContext(ctx, &targets, `
+		SELECT s.id, s.name, s.file_id
+		FROM stamps s
+		WHERE (
+				NOT EXISTS (SELECT 1 FROM stamp_descriptions d WHERE d.stamp_id = s.id)
+				OR EXISTS (
+					SELECT 1 FROM stamp_descriptions d
+					WHERE d.stamp_id = s.id AND d.creator_id = ? AND d.source = ? AND d.hidden = FALSE
+						AND d.source_file_id IS NOT NULL AND d.source_file_id <> s.file_id
+				)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM description_generation_failures f
+				WHERE f.stamp_id = s.id AND f.file_id = s.file_id
+					AND DATE_ADD(f.failed_at, INTERVAL LEAST(1 << LEAST(f.failures - 1, 5), ?) DAY) > ?
+			)
+		ORDER BY s.count_total DESC, s.id
+		LIMIT ?`, MachineDescriptionCreatorID, DescriptionSourceMachine, descriptionGenerationMaxBackoffDays, time.Now(), limit); err != nil {
+		return nil, fmt.Errorf("select description generation queue: %w", err)
+	}
+
+	return targets, nil
+}
+
+// RecordDescriptionGenerationFailure はスタンプの説明文の生成に失敗したことを記録する。
+// 画像が変わっていれば失敗の回数を数え直す
+func (r *Repository) RecordDescriptionGenerationFailure(ctx context.Context, stampID uuid.UUID, fileID uuid.UUID, reason string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO description_generation_failures (stamp_id, file_id, failures, last_error, failed_at)
+		VALUES (?, ?, 1, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			failures = IF(file_id = VALUES(file_id), failures + 1, 1),
+			file_id = VALUES(file_id), last_error = VALUES(last_error), failed_at = VALUES(failed_at)`,
+		stampID, fileID, reason, time.Now()); err != nil {
+		return fmt.Errorf("record description generation failure: %w", err)
+	}
+
+	return nil
+}
+
+// SaveMachineDescription は生成した説明文を日本語の説明文として保存する。既に生成した説明文があれば置き換える
+func (r *Repository) SaveMachineDescription(ctx context.Context, d MachineDescription) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	action := DescriptionActionUpdate
+	if _, err := lockDescription(ctx, tx, d.StampID, MachineDescriptionCreatorID, DefaultDescriptionLang); err != nil {
+		if !errors.Is(err, ErrDescriptionNotFound) {
+			return err
+		}
+		action = DescriptionActionCreate
+	}
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO stamp_descriptions (stamp_id, description, creator_id, lang, source, model, confidence, source_file_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			description = VALUES(description), source = VALUES(source), model = VALUES(model),
+			confidence = VALUES(confidence), source_file_id = VALUES(source_file_id), updated_at = VALUES(updated_at)`,
+		d.StampID, d.Description, MachineDescriptionCreatorID, DefaultDescriptionLang, DescriptionSourceMachine, d.Model, d.Confidence, d.FileID, now, now); err != nil {
+		return fmt.Errorf("upsert machine description: %w", err)
+	}
+	fields, err := selectDescriptionFields(ctx, tx, d.StampID, MachineDescriptionCreatorID, DefaultDescriptionLang)
+	if err != nil {
+		return err
+	}
+	if err := insertDescriptionRevision(ctx, tx, d.StampID, MachineDescriptionCreatorID, DefaultDescriptionLang, MachineDescriptionCreatorID, action, &d.Description, fields); err != nil {
+		return err
+	}
+	if err := replaceDescriptionReferences(ctx, tx, d.StampID, MachineDescriptionCreatorID, DefaultDescriptionLang, d.Description); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM description_generation_failures WHERE stamp_id = ?", d.StampID); err != nil {
+		return fmt.Errorf("delete description generation failure: %w", err)
+	}
+
+	return tx.Commit()
+}
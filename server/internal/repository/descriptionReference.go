@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/traP-jp/1m25_11/server/internal/markup"
+)
+
+// MentioningStamp は説明文でスタンプに言及しているスタンプと、その説明文を書いたユーザー
+type MentioningStamp struct {
+	ID        uuid.UUID `db:"id" json:"stamp_id"`
+	Name      string    `db:"name" json:"stamp_name"`
+	FileID    uuid.UUID `db:"file_id" json:"file_id"`
+	CreatorID uuid.UUID `db:"creator_id" json:"creator_id"`
+	Lang      string    `db:"lang" json:"lang"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// GetStampsByNames は名前からスタンプを引く。存在しない名前は結果に含まれない
+func (r *Repository) GetStampsByNames(ctx context.Context, names []string) ([]*StampSummary, error) {
+	stamps := []*StampSummary{}
+	if len(names) == 0 {
+		return stamps, nil
+	}
+	query, args, err := sqlx.In("SELECT id, name, file_id FROM stamps WHERE name IN (?)", names)
+	if err != nil {
+		return nil, fmt.Errorf("build select stamps by names query: %w", err)
+	}
+	if err := r.db.SelectContext(ctx, &stamps, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("select stamps by names: %w", err)
+	}
+
+	return stamps, nil
+}
+
+// replaceDescriptionReferences は説明文から参照しているスタンプを取り出して参照を置き換える。存在しないスタンプへの参照は記録しない
+func replaceDescriptionReferences(ctx context.Context, tx *sqlx.Tx, stampID uuid.UUID, creatorID uuid.UUID, lang string, description string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM description_stamp_references WHERE stamp_id = ? AND creator_id = ? AND lang = ?", stampID, creatorID, lang); err != nil {
+		return fmt.Errorf("delete description references: %w", err)
+	}
+	names := markup.ExtractStampNames(description)
+	if len(names) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In(`
+		INSERT INTO description_stamp_references (stamp_id, creator_id, lang, referenced_stamp_id)
+		SELECT ?, ?, ?, id FROM stamps WHERE name IN (?)`, stampID, creatorID, lang, names)
+	if err != nil {
+		return fmt.Errorf("build insert description references query: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("insert description references: %w", err)
+	}
+
+	return nil
+}
+
+// GetMentioningStamps は説明文で stampID のスタンプに言及しているスタンプを、説明文の更新が新しい順に返す。
+// 自分自身の説明文での言及 (説明文で自分の名前を書いた場合) は含めない
+func (r *Repository) GetMentioningStamps(ctx context.Context, stampID uuid.UUID) ([]*MentioningStamp, error) {
+	stamps := []*MentioningStamp{}
+	if err := r.db.SelectContext(ctx, &stamps, `
+		SELECT s.id, s.name, s.file_id, d.creator_id, d.lang, d.updated_at
+		FROM description_stamp_references ref
+		JOIN stamp_descriptions d ON d.stamp_id = ref.stamp_id AND d.creator_id = ref.creator_id AND d.lang = ref.lang
+		JOIN stamps s ON s.id = ref.stamp_id
+		WHERE ref.referenced_stamp_id = ? AND ref.stamp_id <> ref.referenced_stamp_id AND d.hidden = FALSE
+		ORDER BY d.updated_at DESC`, stampID); err != nil {
+		return nil, fmt.Errorf("select mentioning stamps: %w", err)
+	}
+
+	return stamps, nil
+}
+
+// RebuildDescriptionReferences はすべての説明文の参照を作り直す。
+// 参照はスタンプ名で書かれているので、スタンプの追加や名前の変更の後に呼んで参照先を追従させる
+func (r *Repository) RebuildDescriptionReferences(ctx context.Context) error {
+	descriptions := []stampDescriptionData{}
+	if err := r.db.SelectContext(ctx, &descriptions, "SELECT stamp_id, description, creator_id, lang, created_at, updated_at FROM stamp_descriptions"); err != nil {
+		return fmt.Errorf("select descriptions: %w", err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, d := range descriptions {
+		if err := replaceDescriptionReferences(ctx, tx, d.StampID, d.CreatorID, d.Lang, d.Description); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
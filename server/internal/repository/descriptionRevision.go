@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	DescriptionActionCreate   = "create"
+	DescriptionActionUpdate   = "update"
+	DescriptionActionDelete   = "delete"
+	DescriptionActionRollback = "rollback"
+)
+
+// DescriptionRevision は説明文の版。Description と構造化された項目は操作後の内容で、削除された状態なら Description は nil
+type DescriptionRevision struct {
+	StampID     uuid.UUID `db:"stamp_id"`
+	CreatorID   uuid.UUID `db:"creator_id"`
+	Lang        string    `db:"lang"`
+	Revision    int       `db:"revision"`
+	Action      string    `db:"action"`
+	Description *string   `db:"description"`
+	DescriptionFields
+	DescriptionProvenance
+	SourceFileID *uuid.UUID `db:"source_file_id"`
+	EditorID     uuid.UUID  `db:"editor_id"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// descriptionProvenanceRow は説明文の出所と、生成に使ったスタンプ画像
+type descriptionProvenanceRow struct {
+	DescriptionProvenance
+	SourceFileID *uuid.UUID `db:"source_file_id"`
+}
+
+var ErrDescriptionRevisionNotFound = errors.New("description revision not found")
+
+// lockDescription は説明文を更新のためにロックして現在の本文を返す
+func lockDescription(ctx context.Context, tx *sqlx.Tx, stampID uuid.UUID, creatorID uuid.UUID, lang string) (string, error) {
+	var description string
+	if err := tx.GetContext(ctx, &description, "SELECT description FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ? AND lang = ? FOR UPDATE", stampID, creatorID, lang); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrDescriptionNotFound
+		}
+
+		return "", fmt.Errorf("select description: %w", err)
+	}
+
+	return description, nil
+}
+
+// insertDescriptionRevision は (stampID, creatorID, lang) の説明文の次の版を記録する。
+// 出所は書き込み後の説明文のものを記録するので、説明文を書き込んでから呼び出す
+func insertDescriptionRevision(ctx context.Context, tx *sqlx.Tx, stampID uuid.UUID, creatorID uuid.UUID, lang string, editorID uuid.UUID, action string, description *string, fields DescriptionFields) error {
+	var current int
+	if err := tx.GetContext(ctx, &current, "SELECT COALESCE(MAX(revision), 0) FROM stamp_description_revisions WHERE stamp_id = ? AND creator_id = ? AND lang = ? FOR UPDATE", stampID, creatorID, lang); err != nil {
+		return fmt.Errorf("select current description revision: %w", err)
+	}
+	provenance := descriptionProvenanceRow{DescriptionProvenance: DescriptionProvenance{Source: DescriptionSourceHuman}}
+	if err := tx.GetContext(ctx, &provenance, "SELECT source, model, confidence, source_file_id FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ? AND lang = ?", stampID, creatorID, lang); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("select description provenance: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO stamp_description_revisions (stamp_id, creator_id, lang, revision, action, description, meaning, usage_notes, origin, examples, source, model, confidence, source_file_id, editor_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		stampID, creatorID, lang, current+1, action, description, fields.Meaning, fields.Usage, fields.Origin, fields.Examples,
+		provenance.Source, provenance.Model, provenance.Confidence, provenance.SourceFileID, editorID, time.Now()); err != nil {
+		return fmt.Errorf("insert description revision: %w", err)
+	}
+
+	return nil
+}
+
+// GetDescriptionRevisions はスタンプの説明文の版を書いたユーザーと言語ごとに古い順で返す。creatorID や lang を指定するとその説明文に絞る。
+// includeHidden が false のときは、通報で非表示にされた説明文の版を含めない
+func (r *Repository) GetDescriptionRevisions(ctx context.Context, stampID uuid.UUID, creatorID *uuid.UUID, lang *string, includeHidden bool) ([]*DescriptionRevision, error) {
+	query := "SELECT stamp_id, creator_id, lang, revision, action, description, meaning, usage_notes, origin, examples, editor_id, created_at FROM stamp_description_revisions r WHERE stamp_id = ?"
+	args := []any{stampID}
+	if !includeHidden {
+		query += " AND NOT EXISTS (SELECT 1 FROM stamp_descriptions d WHERE d.stamp_id = r.stamp_id AND d.creator_id = r.creator_id AND d.lang = r.lang AND d.hidden = TRUE)"
+	}
+	if creatorID != nil {
+		query += " AND creator_id = ?"
+		args = append(args, *creatorID)
+	}
+	if lang != nil {
+		query += " AND lang = ?"
+		args = append(args, *lang)
+	}
+	query += " ORDER BY creator_id, lang, revision"
+
+	revisions := []*DescriptionRevision{}
+	if err := r.db.SelectContext(ctx, &revisions, query, args...); err != nil {
+		return nil, fmt.Errorf("select description revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// RollbackDescription は creatorID の lang の説明文を指定した版の内容と出所に戻し、editorID による巻き戻しとして記録する。
+// 指定した版が削除された状態であれば説明文を削除する。
+func (r *Repository) RollbackDescription(ctx context.Context, stampID uuid.UUID, creatorID uuid.UUID, lang string, revision int, editorID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var target DescriptionRevision
+	if err := tx.GetContext(ctx, &target, `
+		SELECT stamp_id, creator_id, lang, revision, action, description, meaning, usage_notes, origin, examples, source, model, confidence, source_file_id, editor_id, created_at
+		FROM stamp_description_revisions WHERE stamp_id = ? AND creator_id = ? AND lang = ? AND revision = ?`,
+		stampID, creatorID, lang, revision); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDescriptionRevisionNotFound
+		}
+
+		return fmt.Errorf("select description revision: %w", err)
+	}
+
+	_, err = lockDescription(ctx, tx, stampID, creatorID, lang)
+	exists := err == nil
+	if err != nil && !errors.Is(err, ErrDescriptionNotFound) {
+		return err
+	}
+
+	now := time.Now()
+	switch {
+	case target.Description == nil && exists:
+		if _, err := tx.ExecContext(ctx, "DELETE FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ? AND lang = ?", stampID, creatorID, lang); err != nil {
+			return fmt.Errorf("delete description: %w", err)
+		}
+	case target.Description != nil && exists:
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE stamp_descriptions SET description = ?, meaning = ?, usage_notes = ?, origin = ?, examples = ?,
+				source = ?, model = ?, confidence = ?, source_file_id = ?, updated_at = ?
+			WHERE stamp_id = ? AND creator_id = ? AND lang = ?`,
+			*target.Description, target.Meaning, target.Usage, target.Origin, target.Examples,
+			target.Source, target.Model, target.Confidence, target.SourceFileID, now, stampID, creatorID, lang); err != nil {
+			return fmt.Errorf("update description: %w", err)
+		}
+	case target.Description != nil:
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO stamp_descriptions (stamp_id, description, meaning, usage_notes, origin, examples, source, model, confidence, source_file_id, creator_id, lang, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			stampID, *target.Description, target.Meaning, target.Usage, target.Origin, target.Examples,
+			target.Source, target.Model, target.Confidence, target.SourceFileID, creatorID, lang, now, now); err != nil {
+			return fmt.Errorf("restore description: %w", err)
+		}
+	}
+	if err := insertDescriptionRevision(ctx, tx, stampID, creatorID, lang, editorID, DescriptionActionRollback, target.Description, target.DescriptionFields); err != nil {
+		return err
+	}
+	if target.Description != nil {
+		if err := replaceDescriptionReferences(ctx, tx, stampID, creatorID, lang, *target.Description); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
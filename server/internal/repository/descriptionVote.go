@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// wilsonZ は信頼度95%に対応する標準正規分布の分位点
+const wilsonZ = 1.96
+
+var ErrCannotVoteOwnDescription = errors.New("cannot vote on own description")
+
+// wilsonLowerBound は賛成 up 票・反対 down 票に対するウィルソンスコア区間の下限を返す。票がなければ0
+func wilsonLowerBound(up int, down int) float64 {
+	n := float64(up + down)
+	if n == 0 {
+		return 0
+	}
+	p := float64(up) / n
+	z2 := wilsonZ * wilsonZ
+
+	return (p + z2/(2*n) - wilsonZ*math.Sqrt((p*(1-p)+z2/(4*n))/n)) / (1 + z2/n)
+}
+
+// sortDescriptionsByScore は説明文のスコアを計算し、スコアの高い順 (同じなら賛成票の多い順、古い順) に並べる。
+// ユーザーが書いた説明文はスコアに関わらず生成した説明文より前に置く
+func sortDescriptionsByScore(descriptions []*StampDescription) {
+	for _, d := range descriptions {
+		d.Score = wilsonLowerBound(d.Upvotes, d.Downvotes)
+	}
+	sort.SliceStable(descriptions, func(i, j int) bool {
+		a, b := descriptions[i], descriptions[j]
+		if aMachine, bMachine := a.Source == DescriptionSourceMachine, b.Source == DescriptionSourceMachine; aMachine != bMachine {
+			return bMachine
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Upvotes != b.Upvotes {
+			return a.Upvotes > b.Upvotes
+		}
+
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
+
+// VoteDescription は creatorID が書いた lang の説明文に voterID の票 (1 または -1) を入れる。既に投票していれば上書きする
+func (r *Repository) VoteDescription(ctx context.Context, stampID uuid.UUID, creatorID uuid.UUID, lang string, voterID uuid.UUID, value int) error {
+	if creatorID == voterID {
+		return ErrCannotVoteOwnDescription
+	}
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ? AND lang = ?", stampID, creatorID, lang); err != nil {
+		return fmt.Errorf("select description: %w", err)
+	}
+	if count == 0 {
+		return ErrDescriptionNotFound
+	}
+
+	now := time.Now()
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO description_votes (stamp_id, creator_id, lang, voter_id, value, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE value = VALUES(value), updated_at = VALUES(updated_at)`,
+		stampID, creatorID, lang, voterID, value, now, now); err != nil {
+		return fmt.Errorf("upsert description vote: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteDescriptionVote(ctx context.Context, stampID uuid.UUID, creatorID uuid.UUID, lang string, voterID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM description_votes WHERE stamp_id = ? AND creator_id = ? AND lang = ? AND voter_id = ?", stampID, creatorID, lang, voterID); err != nil {
+		return fmt.Errorf("delete description vote: %w", err)
+	}
+
+	return nil
+}
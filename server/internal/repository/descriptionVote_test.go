@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWilsonLowerBound(t *testing.T) {
+	if got := wilsonLowerBound(0, 0); got != 0 {
+		t.Errorf("wilsonLowerBound(0, 0) = %v, want 0", got)
+	}
+
+	// 後ろほど下限が高くなる
+	ordered := [][2]int{{0, 10}, {0, 1}, {1, 1}, {1, 0}, {5, 1}, {10, 0}, {100, 0}}
+	for i := 1; i < len(ordered); i++ {
+		lower := wilsonLowerBound(ordered[i-1][0], ordered[i-1][1])
+		higher := wilsonLowerBound(ordered[i][0], ordered[i][1])
+		if lower >= higher {
+			t.Errorf("wilsonLowerBound%v = %v, want less than wilsonLowerBound%v = %v", ordered[i-1], lower, ordered[i], higher)
+		}
+	}
+
+	for _, votes := range ordered {
+		if got := wilsonLowerBound(votes[0], votes[1]); got < 0 || got > 1 || math.IsNaN(got) {
+			t.Errorf("wilsonLowerBound%v = %v, want in [0, 1]", votes, got)
+		}
+	}
+}
+
+func TestSortDescriptionsByScore(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	description := func(name string, source string, up int, down int, createdAt time.Time) *StampDescription {
+		d := &StampDescription{Description: name, Upvotes: up, Downvotes: down, CreatedAt: createdAt}
+		d.Source = source
+
+		return d
+	}
+
+	tests := []struct {
+		name         string
+		descriptions []*StampDescription
+		want         []string
+	}{
+		{"empty", []*StampDescription{}, []string{}},
+		{
+			"higher score first",
+			[]*StampDescription{
+				description("low", DescriptionSourceHuman, 1, 0, base),
+				description("high", DescriptionSourceHuman, 10, 0, base),
+				description("negative", DescriptionSourceHuman, 0, 3, base),
+			},
+			[]string{"high", "low", "negative"},
+		},
+		{
+			"no votes ties broken by age",
+			[]*StampDescription{
+				description("newer", DescriptionSourceHuman, 0, 0, base.Add(time.Hour)),
+				description("older", DescriptionSourceHuman, 0, 0, base),
+			},
+			[]string{"older", "newer"},
+		},
+		{
+			"same score ties broken by upvotes then age",
+			[]*StampDescription{
+				description("zero newer", DescriptionSourceHuman, 0, 0, base.Add(time.Hour)),
+				description("zero older", DescriptionSourceHuman, 0, 0, base),
+				description("one up", DescriptionSourceHuman, 1, 0, base.Add(2*time.Hour)),
+			},
+			[]string{"one up", "zero older", "zero newer"},
+		},
+		{
+			"human before machine regardless of score",
+			[]*StampDescription{
+				description("machine", DescriptionSourceMachine, 50, 0, base),
+				description("human", DescriptionSourceHuman, 0, 5, base.Add(time.Hour)),
+			},
+			[]string{"human", "machine"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortDescriptionsByScore(tt.descriptions)
+			got := make([]string, len(tt.descriptions))
+			for i, d := range tt.descriptions {
+				got[i] = d.Description
+				if want := wilsonLowerBound(d.Upvotes, d.Downvotes); d.Score != want {
+					t.Errorf("%s: Score = %v, want %v", d.Description, d.Score, want)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortDescriptionsByScore() order = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DormantStamp struct {
+	ID         uuid.UUID  `db:"id"`
+	Name       string     `db:"name"`
+	FileID     uuid.UUID  `db:"file_id"`
+	CreatorID  uuid.UUID  `db:"creator_id"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+}
+
+// GetDormantStamps は since 以降 stamp_daily_usages に使用記録がないスタンプを、最終使用日の古い順に返す。
+// since 以降に作成されたスタンプは対象外。
+func (r *Repository) GetDormantStamps(ctx context.Context, since time.Time, limit int, offset int) ([]DormantStamp, error) {
+	stamps := []DormantStamp{}
+	if err := r.db.SelectContext(ctx, &stamps, `
+		SELECT s.id, s.name, s.file_id, s.creator_id, s.created_at, MAX(u.date) AS last_used_at
+		FROM stamps s
+		LEFT JOIN stamp_daily_usages u ON u.stamp_id = s.id
+		WHERE s.created_at < ?
+		GROUP BY s.id
+		HAVING last_used_at IS NULL OR last_used_at < ?
+		ORDER BY last_used_at IS NOT NULL, last_used_at ASC, s.name ASC
+		LIMIT ? OFFSET ?`, since, since, limit, offset); err != nil {
+		return nil, fmt.Errorf("select dormant stamps: %w", err)
+	}
+
+	return stamps, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type (
+	FeedStamp struct {
+		ID        uuid.UUID `db:"id"`
+		Name      string    `db:"name"`
+		FileID    uuid.UUID `db:"file_id"`
+		CreatorID uuid.UUID `db:"creator_id"`
+		CreatedAt time.Time `db:"created_at"`
+		UpdatedAt time.Time `db:"updated_at"`
+	}
+
+	FeedDescription struct {
+		StampID     uuid.UUID `db:"stamp_id"`
+		StampName   string    `db:"stamp_name"`
+		Description string    `db:"description"`
+		CreatorID   uuid.UUID `db:"creator_id"`
+		CreatedAt   time.Time `db:"created_at"`
+		UpdatedAt   time.Time `db:"updated_at"`
+	}
+)
+
+var ErrFeedTokenNotFound = errors.New("feed token not found")
+
+// SaveFeedToken はユーザーのフィードトークンを置き換える (古いトークンは無効になる)
+func (r *Repository) SaveFeedToken(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO feed_tokens (user_id, token_hash, created_at) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash), created_at = VALUES(created_at)`,
+		userID, tokenHash, time.Now()); err != nil {
+		return fmt.Errorf("failed to save feed token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteFeedToken(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM feed_tokens WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("failed to delete feed token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetUserIDByFeedToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	if err := r.db.GetContext(ctx, &userID, "SELECT user_id FROM feed_tokens WHERE token_hash = ?", tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrFeedTokenNotFound
+		}
+
+		return uuid.Nil, fmt.Errorf("failed to get feed token: %w", err)
+	}
+
+	return userID, nil
+}
+
+// GetNewStamps は作成日時の新しい順にスタンプを返す。tagNames を指定するといずれかのタグが付いたものに絞り込む。
+func (r *Repository) GetNewStamps(ctx context.Context, tagNames []string, limit int) ([]FeedStamp, error) {
+	query := "SELECT s.id, s.name, s.file_id, s.creator_id, s.created_at, s.updated_at FROM stamps s"
+	args := []interface{}{}
+	if len(tagNames) > 0 {
+		query += " WHERE " + stampHasAnyTagClause
+		args = append(args, tagNames, tagNames)
+	}
+	query += " ORDER BY s.created_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IN query: %w", err)
+	}
+
+	stamps := []FeedStamp{}
+	if err := r.db.SelectContext(ctx, &stamps, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("select new stamps: %w", err)
+	}
+
+	return stamps, nil
+}
+
+// GetRecentDescriptions は更新日時の新しい順に説明文を返す。tagNames を指定するといずれかのタグが付いたスタンプに絞り込む。
+func (r *Repository) GetRecentDescriptions(ctx context.Context, tagNames []string, limit int) ([]FeedDescription, error) {
+	query := `
+		SELECT d.stamp_id, s.name AS stamp_name, d.description, d.creator_id, d.created_at, d.updated_at
+		FROM stamp_descriptions d
+		JOIN stamps s ON s.id = d.stamp_id
+		WHERE d.hidden = FALSE`
+	args := []interface{}{}
+	if len(tagNames) > 0 {
+		query += " AND " + stampHasAnyTagClause
+		args = append(args, tagNames, tagNames)
+	}
+	query += " ORDER BY d.updated_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IN query: %w", err)
+	}
+
+	descriptions := []FeedDescription{}
+	if err := r.db.SelectContext(ctx, &descriptions, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("select recent descriptions: %w", err)
+	}
+
+	return descriptions, nil
+}
+
+// stampHasAnyTagClause はスタンプ s にいずれかのタグ名または別名 (sqlx.In で展開する) のタグが付いていることを表す条件。
+// 引数にはタグ名の一覧を2回渡す。
+const stampHasAnyTagClause = `EXISTS (
+	SELECT 1 FROM stamp_tags st JOIN tags t ON st.tag_id = t.id
+	WHERE st.stamp_id = s.id AND (t.name IN (?) OR t.id IN (SELECT tag_id FROM tag_aliases WHERE name IN (?))))`
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	CreatorStats struct {
+		UserID     uuid.UUID `db:"user_id"`
+		StampCount int       `db:"stamp_count"`
+		TotalUsage int64     `db:"total_usage"`
+	}
+
+	ContributorStats struct {
+		UserID           uuid.UUID `db:"user_id"`
+		TagCount         int       `db:"tag_count"`
+		StampTagCount    int       `db:"stamp_tag_count"`
+		DescriptionCount int       `db:"description_count"`
+		Total            int       `db:"total"`
+	}
+)
+
+// GetCreatorStats はスタンプ作成者ごとの作成数と、作成したスタンプの累計使用回数を返す
+func (r *Repository) GetCreatorStats(ctx context.Context) ([]CreatorStats, error) {
+	stats := []CreatorStats{}
+	if err := r.db.SelectContext(ctx, &stats, `
+		SELECT creator_id AS user_id, COUNT(*) AS stamp_count, COALESCE(SUM(count_total), 0) AS total_usage
+		FROM stamps
+		WHERE is_unicode = FALSE
+		GROUP BY creator_id`); err != nil {
+		return nil, fmt.Errorf("select creator stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetContributorStats は since 以降にユーザーが作成したタグ・タグ付け・説明文の数を返す
+func (r *Repository) GetContributorStats(ctx context.Context, since time.Time) ([]ContributorStats, error) {
+	stats := []ContributorStats{}
+	if err := r.db.SelectContext(ctx, &stats, `
+		SELECT
+			user_id,
+			SUM(tag) AS tag_count,
+			SUM(stamp_tag) AS stamp_tag_count,
+			SUM(description) AS description_count,
+			COUNT(*) AS total
+		FROM (
+			SELECT creator_id AS user_id, 1 AS tag, 0 AS stamp_tag, 0 AS description
+			FROM tags WHERE created_at >= ?
+			UNION ALL
+			SELECT creator_id, 0, 1, 0 FROM stamp_tags WHERE created_at >= ?
+			UNION ALL
+			SELECT creator_id, 0, 0, 1 FROM stamp_descriptions WHERE created_at >= ?
+		) AS contributions
+		GROUP BY user_id`, since, since, since); err != nil {
+		return nil, fmt.Errorf("select contributor stats: %w", err)
+	}
+
+	return stats, nil
+}
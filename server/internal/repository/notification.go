@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	NotificationTypeTagApproved = "tag_approved"
+	NotificationTypeTagRejected = "tag_rejected"
+)
+
+// Notification はユーザーへのお知らせ。SubjectID, SubjectName は対象 (タグなど) を表し、対象が削除されていても残る
+type Notification struct {
+	ID          uuid.UUID  `db:"id" json:"notification_id"`
+	UserID      uuid.UUID  `db:"user_id" json:"-"`
+	Type        string     `db:"type" json:"type"`
+	SubjectID   *uuid.UUID `db:"subject_id" json:"subject_id"`
+	SubjectName string     `db:"subject_name" json:"subject_name"`
+	Message     string     `db:"message" json:"message"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	ReadAt      *time.Time `db:"read_at" json:"read_at"`
+}
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+func insertNotification(ctx context.Context, tx *sqlx.Tx, n *Notification) error {
+	n.ID, _ = uuid.NewV7()
+	n.CreatedAt = time.Now()
+	if _, err := tx.NamedExecContext(ctx, `
+		INSERT INTO notifications (id, user_id, type, subject_id, subject_name, message, created_at)
+		VALUES (:id, :user_id, :type, :subject_id, :subject_name, :message, :created_at)`, n); err != nil {
+		return fmt.Errorf("insert notification: %w", err)
+	}
+
+	return nil
+}
+
+// GetNotifications はユーザーへのお知らせを新しい順に返す
+func (r *Repository) GetNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]*Notification, error) {
+	query := "SELECT id, user_id, type, subject_id, subject_name, message, created_at, read_at FROM notifications WHERE user_id = ?"
+	if unreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+
+	notifications := []*Notification{}
+	if err := r.db.SelectContext(ctx, &notifications, query, userID, limit); err != nil {
+		return nil, fmt.Errorf("select notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func (r *Repository) MarkNotificationRead(ctx context.Context, userID uuid.UUID, notificationID uuid.UUID) error {
+	var count int
+	if err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM notifications WHERE id = ? AND user_id = ?", notificationID, userID); err != nil {
+		return fmt.Errorf("select notification: %w", err)
+	}
+	if count == 0 {
+		return ErrNotificationNotFound
+	}
+	if _, err := r.db.ExecContext(ctx, "UPDATE notifications SET read_at = ? WHERE id = ? AND read_at IS NULL", time.Now(), notificationID); err != nil {
+		return fmt.Errorf("update notification: %w", err)
+	}
+
+	return nil
+}
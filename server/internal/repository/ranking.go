@@ -2,29 +2,252 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-type StampRankingResult struct {
-	StampID      uuid.UUID `db:"id"`
-	TotalCount   int       `db:"count_total"`
-	MonthlyCount int       `db:"count_monthly"`
+type RankingPeriod string
+
+const (
+	RankingPeriodDay   RankingPeriod = "day"
+	RankingPeriodWeek  RankingPeriod = "week"
+	RankingPeriodMonth RankingPeriod = "month"
+	RankingPeriodYear  RankingPeriod = "year"
+	RankingPeriodAll   RankingPeriod = "all"
+)
+
+// RankingPeriods はスナップショットを計算する期間の一覧
+var RankingPeriods = []RankingPeriod{
+	RankingPeriodDay,
+	RankingPeriodWeek,
+	RankingPeriodMonth,
+	RankingPeriodYear,
+	RankingPeriodAll,
+}
+
+// rankingPeriodDays は各期間の日数 (all は全期間なので 0)
+var rankingPeriodDays = map[RankingPeriod]int{
+	RankingPeriodDay:   1,
+	RankingPeriodWeek:  7,
+	RankingPeriodMonth: 30,
+	RankingPeriodYear:  365,
+	RankingPeriodAll:   0,
+}
+
+var ErrInvalidRankingPeriod = errors.New("invalid ranking period")
+
+// rankingEpoch は全期間集計の起点 (traQ のサービス開始より前であればよい)
+var rankingEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// rankingInsertChunkSize はプレースホルダ数の上限を超えないように一度に INSERT する行数
+const rankingInsertChunkSize = 1000
+
+func ParseRankingPeriod(s string) (RankingPeriod, error) {
+	p := RankingPeriod(s)
+	if _, ok := rankingPeriodDays[p]; !ok {
+		return "", ErrInvalidRankingPeriod
+	}
+
+	return p, nil
+}
+
+// Start は today を終端とした期間の開始日を返す (all の場合は rankingEpoch)
+func (p RankingPeriod) Start(today time.Time) time.Time {
+	days := rankingPeriodDays[p]
+	if days == 0 {
+		return rankingEpoch
+	}
+
+	return today.AddDate(0, 0, -days)
 }
 
-func (r *Repository) GetRanking(ctx context.Context) ([]StampRankingResult, error) {
-	var results []StampRankingResult
+type (
+	StampRankingResult struct {
+		StampID       uuid.UUID `db:"stamp_id"`
+		StampName     string    `db:"stamp_name"`
+		FileID        uuid.UUID `db:"file_id"`
+		Rank          int       `db:"current_rank"`
+		Count         int64     `db:"count"`
+		PreviousRank  *int      `db:"previous_rank"`
+		PreviousCount int64     `db:"previous_count"`
+		ComputedAt    time.Time `db:"computed_at"`
+	}
+
+	GetRankingParams struct {
+		Period    RankingPeriod
+		Order     string
+		Limit     int
+		IsUnicode *bool
+		TagName   string
+		CreatorID *uuid.UUID
+	}
+
+	rankingSnapshotData struct {
+		Period        RankingPeriod `db:"period"`
+		StampID       uuid.UUID     `db:"stamp_id"`
+		Rank          int           `db:"current_rank"`
+		Count         int64         `db:"count"`
+		PreviousRank  *int          `db:"previous_rank"`
+		PreviousCount int64         `db:"previous_count"`
+		ComputedAt    time.Time     `db:"computed_at"`
+	}
+
+	stampPeriodCount struct {
+		StampID       uuid.UUID `db:"stamp_id"`
+		Count         int64     `db:"count"`
+		PreviousCount int64     `db:"previous_count"`
+	}
+)
+
+func (r *Repository) GetRanking(ctx context.Context, params GetRankingParams) ([]StampRankingResult, error) {
 	query := `
-        SELECT
-            id,count_total,count_monthly FROM stamps`
-	err := r.db.SelectContext(ctx, &results, query)
-	if err != nil {
-		return nil, err
+		SELECT
+			r.stamp_id, s.name AS stamp_name, s.file_id,
+			r.current_rank, r.count, r.previous_rank, r.previous_count, r.computed_at
+		FROM stamp_ranking_snapshots r
+		JOIN stamps s ON s.id = r.stamp_id
+		WHERE r.period = ?`
+	args := []interface{}{params.Period}
+
+	if params.IsUnicode != nil {
+		query += " AND s.is_unicode = ?"
+		args = append(args, *params.IsUnicode)
+	}
+	if params.CreatorID != nil {
+		query += " AND s.creator_id = ?"
+		args = append(args, *params.CreatorID)
+	}
+	if params.TagName != "" {
+		query += ` AND EXISTS (
+			SELECT 1 FROM stamp_tags st JOIN tags t ON st.tag_id = t.id
+			WHERE st.stamp_id = s.id AND (t.name = ? OR t.id IN (SELECT tag_id FROM tag_aliases WHERE name = ?)))`
+		args = append(args, params.TagName, params.TagName)
 	}
 
-	if results == nil {
-		return []StampRankingResult{}, nil
+	if params.Order == "desc" {
+		query += " ORDER BY r.current_rank DESC, s.name DESC"
+	} else {
+		query += " ORDER BY r.current_rank ASC, s.name ASC"
+	}
+	if params.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, params.Limit)
+	}
+
+	results := []StampRankingResult{}
+	if err := r.db.SelectContext(ctx, &results, query, args...); err != nil {
+		return nil, fmt.Errorf("select ranking: %w", err)
 	}
 
 	return results, nil
 }
+
+// RefreshRankingSnapshots は stamp_daily_usages から全期間のランキングを再計算する。
+// today より前の日付を集計対象とし、直前の同じ長さの期間を前回順位として保存する。
+func (r *Repository) RefreshRankingSnapshots(ctx context.Context, today time.Time) error {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, period := range RankingPeriods {
+		days := rankingPeriodDays[period]
+		// all の場合は全期間を、前回は1日前までの全期間を集計する
+		currentFrom, previousFrom := period.Start(today), rankingEpoch
+		previousUntil := today.AddDate(0, 0, -1)
+		if days > 0 {
+			previousFrom = today.AddDate(0, 0, -2*days)
+			previousUntil = currentFrom
+		}
+
+		counts := []stampPeriodCount{}
+		if err := tx.SelectContext(ctx, &counts, `
+			SELECT
+				stamp_id,
+				COALESCE(SUM(CASE WHEN date >= ? THEN reaction_count + message_count END), 0) AS count,
+				COALESCE(SUM(CASE WHEN date < ? THEN reaction_count + message_count END), 0) AS previous_count
+			FROM stamp_daily_usages
+			WHERE date >= ? AND date < ?
+			GROUP BY stamp_id`,
+			currentFrom, previousUntil, previousFrom, today); err != nil {
+			return fmt.Errorf("aggregate %s usages: %w", period, err)
+		}
+
+		previousRanks := rankByCount(counts, func(c stampPeriodCount) int64 { return c.PreviousCount })
+		currentRanks := rankByCount(counts, func(c stampPeriodCount) int64 { return c.Count })
+
+		rows := make([]rankingSnapshotData, 0, len(currentRanks))
+		for _, c := range counts {
+			rank, ok := currentRanks[c.StampID]
+			if !ok {
+				continue
+			}
+			row := rankingSnapshotData{
+				Period:        period,
+				StampID:       c.StampID,
+				Rank:          rank,
+				Count:         c.Count,
+				PreviousCount: c.PreviousCount,
+				ComputedAt:    now,
+			}
+			if prev, ok := previousRanks[c.StampID]; ok {
+				row.PreviousRank = &prev
+			}
+			rows = append(rows, row)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM stamp_ranking_snapshots WHERE period = ?", period); err != nil {
+			return fmt.Errorf("delete %s snapshot: %w", period, err)
+		}
+		for start := 0; start < len(rows); start += rankingInsertChunkSize {
+			end := min(start+rankingInsertChunkSize, len(rows))
+			if _, err := tx.NamedExecContext(ctx, `
+				INSERT INTO stamp_ranking_snapshots
+					(period, stamp_id, current_rank, count, previous_rank, previous_count, computed_at)
+				VALUES (:period, :stamp_id, :current_rank, :count, :previous_rank, :previous_count, :computed_at)
+			`, rows[start:end]); err != nil {
+				return fmt.Errorf("insert %s snapshot: %w", period, err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// rankByCount は使用回数の多い順に順位を付ける (同数は同順位、使用回数0は順位なし)
+func rankByCount(counts []stampPeriodCount, key func(stampPeriodCount) int64) map[uuid.UUID]int {
+	sorted := make([]stampPeriodCount, 0, len(counts))
+	for _, c := range counts {
+		if key(c) > 0 {
+			sorted = append(sorted, c)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if key(sorted[i]) != key(sorted[j]) {
+			return key(sorted[i]) > key(sorted[j])
+		}
+
+		return strings.Compare(sorted[i].StampID.String(), sorted[j].StampID.String()) < 0
+	})
+
+	ranks := make(map[uuid.UUID]int, len(sorted))
+	for i, c := range sorted {
+		if i > 0 && key(c) == key(sorted[i-1]) {
+			ranks[c.StampID] = ranks[sorted[i-1].StampID]
+
+			continue
+		}
+		ranks[c.StampID] = i + 1
+	}
+
+	return ranks
+}
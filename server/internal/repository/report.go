@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	ReportTargetDescription = "description"
+	ReportTargetTag         = "tag"
+	ReportTargetStampTag    = "stamp_tag"
+
+	ReportStatusOpen     = "open"
+	ReportStatusResolved = "resolved"
+
+	ReportActionHide    = "hide"
+	ReportActionDelete  = "delete"
+	ReportActionDismiss = "dismiss"
+)
+
+type (
+	// ReportTarget は通報の対象。説明文は StampID と CreatorID と Lang、タグは TagID、タグ付けは StampID と TagID で表す
+	ReportTarget struct {
+		Type      string     `db:"target_type" json:"target_type"`
+		StampID   *uuid.UUID `db:"stamp_id" json:"stamp_id"`
+		CreatorID *uuid.UUID `db:"creator_id" json:"creator_id"`
+		Lang      *string    `db:"lang" json:"lang"`
+		TagID     *uuid.UUID `db:"tag_id" json:"tag_id"`
+	}
+
+	Report struct {
+		ID uuid.UUID `db:"id" json:"report_id"`
+		ReportTarget
+		Reason     string     `db:"reason" json:"reason"`
+		ReporterID uuid.UUID  `db:"reporter_id" json:"reporter_id"`
+		Status     string     `db:"status" json:"status"`
+		Resolution *string    `db:"resolution" json:"resolution"`
+		ResolverID *uuid.UUID `db:"resolver_id" json:"resolver_id"`
+		ResolvedAt *time.Time `db:"resolved_at" json:"resolved_at"`
+		CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+		// 管理者が確認するための対象の現在の内容 (対象が削除されていれば nil)
+		StampName   *string `db:"stamp_name" json:"stamp_name"`
+		TagName     *string `db:"tag_name" json:"tag_name"`
+		Description *string `db:"description" json:"description"`
+	}
+)
+
+var (
+	ErrReportNotFound        = errors.New("report not found")
+	ErrReportTargetNotFound  = errors.New("report target not found")
+	ErrReportAlreadyExists   = errors.New("report already exists")
+	ErrReportAlreadyResolved = errors.New("report already resolved")
+)
+
+// reportTargetCondition は reports の行が target と同じ対象への通報であることを表す条件と引数
+func reportTargetCondition(target ReportTarget) (string, []any) {
+	return "target_type = ? AND stamp_id <=> ? AND creator_id <=> ? AND lang <=> ? AND tag_id <=> ?",
+		[]any{target.Type, target.StampID, target.CreatorID, target.Lang, target.TagID}
+}
+
+// reportTargetExists は通報の対象が存在するかを返す
+func reportTargetExists(ctx context.Context, tx *sqlx.Tx, target ReportTarget) (bool, error) {
+	var query string
+	var args []any
+	switch target.Type {
+	case ReportTargetDescription:
+		query = "SELECT EXISTS(SELECT 1 FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ? AND lang = ?)"
+		args = []any{target.StampID, target.CreatorID, target.Lang}
+	case ReportTargetTag:
+		query = "SELECT EXISTS(SELECT 1 FROM tags WHERE id = ?)"
+		args = []any{target.TagID}
+	case ReportTargetStampTag:
+		query = "SELECT EXISTS(SELECT 1 FROM stamp_tags WHERE stamp_id = ? AND tag_id = ?)"
+		args = []any{target.StampID, target.TagID}
+	default:
+		return false, fmt.Errorf("unknown report target type: %s", target.Type)
+	}
+	var exists bool
+	if err := tx.GetContext(ctx, &exists, query, args...); err != nil {
+		return false, fmt.Errorf("check report target: %w", err)
+	}
+
+	return exists, nil
+}
+
+// CreateReport は通報を作成する。同じユーザーが同じ対象に未対応の通報をしている場合は ErrReportAlreadyExists を返す
+func (r *Repository) CreateReport(ctx context.Context, target ReportTarget, reason string, reporterID uuid.UUID) (*Report, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	exists, err := reportTargetExists(ctx, tx, target)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrReportTargetNotFound
+	}
+	cond, args := reportTargetCondition(target)
+	var duplicated bool
+	if err := tx.GetContext(ctx, &duplicated, "SELECT EXISTS(SELECT 1 FROM reports WHERE "+cond+" AND reporter_id = ? AND status = ?)",
+		append(args, reporterID, ReportStatusOpen)...); err != nil {
+		return nil, fmt.Errorf("check duplicated report: %w", err)
+	}
+	if duplicated {
+		return nil, ErrReportAlreadyExists
+	}
+
+	id, _ := uuid.NewV7()
+	report := &Report{
+		ID:           id,
+		ReportTarget: target,
+		Reason:       reason,
+		ReporterID:   reporterID,
+		Status:       ReportStatusOpen,
+		CreatedAt:    time.Now(),
+	}
+	if _, err := tx.NamedExecContext(ctx, `
+		INSERT INTO reports (id, target_type, stamp_id, creator_id, lang, tag_id, reason, reporter_id, status, created_at)
+		VALUES (:id, :target_type, :stamp_id, :creator_id, :lang, :tag_id, :reason, :reporter_id, :status, :created_at)`, report); err != nil {
+		return nil, fmt.Errorf("insert report: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return report, nil
+}
+
+// GetReports は指定した状態の通報を対象の現在の内容とともに返す。未対応のものは古い順、対応済みのものは新しい順に並べる
+func (r *Repository) GetReports(ctx context.Context, status string, limit int) ([]*Report, error) {
+	order := "r.created_at, r.id"
+	if status != ReportStatusOpen {
+		order = "r.resolved_at DESC, r.id DESC"
+	}
+	reports := []*Report{}
+	if err := r.db.SelectContext(ctx, &reports, `
+		SELECT
+			r.id, r.target_type, r.stamp_id, r.creator_id, r.lang, r.tag_id, r.reason, r.reporter_id,
+			r.status, r.resolution, r.resolver_id, r.resolved_at, r.created_at,
+			s.name AS stamp_name, t.name AS tag_name, d.description
+		FROM reports r
+		LEFT JOIN stamps s ON s.id = r.stamp_id
+		LEFT JOIN tags t ON t.id = r.tag_id
+		LEFT JOIN stamp_descriptions d ON r.target_type = '`+ReportTargetDescription+`' AND d.stamp_id = r.stamp_id AND d.creator_id = r.creator_id AND d.lang = r.lang
+		WHERE r.status = ?
+		ORDER BY `+order+`
+		LIMIT ?`, status, limit); err != nil {
+		return nil, fmt.Errorf("select reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// ResolveReport は通報に対応する。action に応じて対象を非表示・削除するか何もせず、同じ対象への未対応の通報もまとめて対応済みにする
+func (r *Repository) ResolveReport(ctx context.Context, reportID uuid.UUID, action string, resolverID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var report Report
+	if err := tx.GetContext(ctx, &report, "SELECT id, target_type, stamp_id, creator_id, lang, tag_id, status FROM reports WHERE id = ? FOR UPDATE", reportID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReportNotFound
+		}
+
+		return fmt.Errorf("select report: %w", err)
+	}
+	if report.Status != ReportStatusOpen {
+		return ErrReportAlreadyResolved
+	}
+
+	switch action {
+	case ReportActionHide:
+		if err := hideReportTarget(ctx, tx, report.ReportTarget); err != nil {
+			return err
+		}
+	case ReportActionDelete:
+		if err := deleteReportTarget(ctx, tx, report.ReportTarget, resolverID); err != nil {
+			return err
+		}
+	}
+
+	cond, args := reportTargetCondition(report.ReportTarget)
+	if _, err := tx.ExecContext(ctx, "UPDATE reports SET status = ?, resolution = ?, resolver_id = ?, resolved_at = ? WHERE "+cond+" AND status = ?",
+		append([]any{ReportStatusResolved, action, resolverID, time.Now()}, append(args, ReportStatusOpen)...)...); err != nil {
+		return fmt.Errorf("update reports: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	if report.Type != ReportTargetDescription && action != ReportActionDismiss {
+		r.invalidateTagGraph()
+	}
+
+	return nil
+}
+
+func hideReportTarget(ctx context.Context, tx *sqlx.Tx, target ReportTarget) error {
+	var err error
+	switch target.Type {
+	case ReportTargetDescription:
+		_, err = tx.ExecContext(ctx, "UPDATE stamp_descriptions SET hidden = TRUE WHERE stamp_id = ? AND creator_id = ? AND lang = ?", target.StampID, target.CreatorID, target.Lang)
+	case ReportTargetTag:
+		_, err = tx.ExecContext(ctx, "UPDATE tags SET hidden = TRUE WHERE id = ?", target.TagID)
+	case ReportTargetStampTag:
+		_, err = tx.ExecContext(ctx, "UPDATE stamp_tags SET hidden = TRUE WHERE stamp_id = ? AND tag_id = ?", target.StampID, target.TagID)
+	}
+	if err != nil {
+		return fmt.Errorf("hide report target: %w", err)
+	}
+
+	return nil
+}
+
+// deleteReportTarget は通報の対象を削除する。既に削除されていれば何もしない。説明文の削除は管理者による削除として履歴に残す
+func deleteReportTarget(ctx context.Context, tx *sqlx.Tx, target ReportTarget, resolverID uuid.UUID) error {
+	switch target.Type {
+	case ReportTargetDescription:
+		if _, err := lockDescription(ctx, tx, *target.StampID, *target.CreatorID, *target.Lang); err != nil {
+			if errors.Is(err, ErrDescriptionNotFound) {
+				return nil
+			}
+
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM stamp_descriptions WHERE stamp_id = ? AND creator_id = ? AND lang = ?", target.StampID, target.CreatorID, target.Lang); err != nil {
+			return fmt.Errorf("delete description: %w", err)
+		}
+
+		return insertDescriptionRevision(ctx, tx, *target.StampID, *target.CreatorID, *target.Lang, resolverID, DescriptionActionDelete, nil, DescriptionFields{})
+	case ReportTargetTag:
+		if _, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id = ?", target.TagID); err != nil {
+			return fmt.Errorf("delete tag: %w", err)
+		}
+	case ReportTargetStampTag:
+		if _, err := tx.ExecContext(ctx, "DELETE FROM stamp_tags WHERE stamp_id = ? AND tag_id = ?", target.StampID, target.TagID); err != nil {
+			return fmt.Errorf("delete stamp tag: %w", err)
+		}
+	}
+
+	return nil
+}
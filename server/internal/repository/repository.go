@@ -11,7 +11,8 @@ import (
 )
 
 type Repository struct {
-	db *sqlx.DB
+	db       *sqlx.DB
+	tagGraph *tagGraphCache
 }
 type TagDetails struct {
 	ID        uuid.UUID     `db:"id"`
@@ -19,6 +20,9 @@ type TagDetails struct {
 	CreatorID uuid.UUID     `db:"creator_id"`
 	CreatedAt time.Time     `db:"created_at"`
 	UpdatedAt time.Time     `db:"updated_at"`
+	ParentID  *uuid.UUID    `db:"parent_id"`
+	Status    string        `db:"status"`
+	Aliases   []string      `db:"-"`
 	Stamps    []StampForTag `db:"-"`
 }
 
@@ -32,9 +36,20 @@ func (r *Repository) CreateTag(context context.Context, params CreateTagParams)
 	panic("unimplemented")
 }
 
-func (r *Repository) GetTagDetails(ctx context.Context, tagID uuid.UUID) (*TagDetails, error) {
+// GetTagDetails はタグの詳細を返す。通報で非表示にされたタグは includeHidden が false なら見つからないものとして扱い、
+// 非表示にされた紐づけのスタンプは含めない
+func (r *Repository) GetTagDetails(ctx context.Context, tagID uuid.UUID, includeHidden bool) (*TagDetails, error) {
+	tagID, err := r.ResolveTagID(ctx, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT id, name, creator_id, created_at, updated_at, parent_id, status FROM tags WHERE id = ?"
+	if !includeHidden {
+		query += " AND hidden = FALSE"
+	}
 	var tagDetails TagDetails
-	err := r.db.GetContext(ctx, &tagDetails, "SELECT id, name, creator_id, created_at, updated_at FROM tags WHERE id = ?", tagID)
+	err = r.db.GetContext(ctx, &tagDetails, query, tagID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrTagNotFound
@@ -47,13 +62,18 @@ func (r *Repository) GetTagDetails(ctx context.Context, tagID uuid.UUID) (*TagDe
 	err = r.db.SelectContext(ctx, &stamps, `
 		SELECT s.id, s.name, s.file_id FROM stamps s
 		INNER JOIN stamp_tags st ON s.id = st.stamp_id
-		WHERE st.tag_id = ?`, tagID)
+		WHERE st.tag_id = ? AND st.hidden = FALSE`, tagID)
 	if err != nil {
 		return nil, err
 	}
 
 	tagDetails.Stamps = stamps
 
+	tagDetails.Aliases, err = r.GetTagAliases(ctx, tagID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &tagDetails, nil
 }
 
@@ -62,5 +82,5 @@ func (r *Repository) IsAdmin(context context.Context, userID uuid.UUID) (any, er
 }
 
 func New(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: db, tagGraph: &tagGraphCache{}}
 }
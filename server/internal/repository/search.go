@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/traP-jp/1m25_11/server/internal/textanalysis"
 )
 
 type SearchStampsParams struct {
@@ -24,6 +25,14 @@ type SearchStampsParams struct {
 	CountMonthlyMin    *int
 	CountMonthlyMax    *int
 	SortBy             string
+	// IncludeDescendants が true のとき、タグの検索で子孫タグが付いたスタンプも対象にする
+	IncludeDescendants bool
+	// CategoryID が指定されたとき、そのカテゴリのタグが付いたスタンプに絞り込む
+	CategoryID *uuid.UUID
+	// ActiveSince が指定されたとき、この日以降に使われていない (休眠中の) スタンプを除外する
+	ActiveSince *time.Time
+	// Lang が指定されたとき、説明文の検索をその言語の説明文に限る
+	Lang string
 }
 
 type StampForSearch struct {
@@ -35,22 +44,69 @@ type StampForSearch struct {
 	CountMonthly int       `db:"count_monthly"`
 	Tags         string    `db:"tags"`
 	Descriptions string    `db:"descriptions"`
+	// 説明文の構造化された項目。関連度の計算で項目ごとに重みを付けるため別々に返す
+	Meanings string `db:"meanings"`
+	Usages   string `db:"usages"`
+	Origins  string `db:"origins"`
+	Examples string `db:"examples"`
+	// 英語の説明文。英語は単語単位で照合するため、ほかの言語の説明文とは分けて返す
+	EnDescriptions string `db:"en_descriptions"`
+	EnMeanings     string `db:"en_meanings"`
+	EnUsages       string `db:"en_usages"`
+	EnOrigins      string `db:"en_origins"`
+	EnExamples     string `db:"en_examples"`
 }
 
+// searchDescriptionColumns は説明文の各項目を、英語とそれ以外の言語に分けて連結する列
+var searchDescriptionColumns = func() string {
+	fields := []struct{ expr, alias string }{
+		{"sd.description", "descriptions"},
+		{"sd.meaning", "meanings"},
+		{"sd.usage_notes", "usages"},
+		{"sd.origin", "origins"},
+		{`REPLACE(sd.examples, '\n', ' ')`, "examples"},
+	}
+	columns := make([]string, 0, len(fields)*2)
+	for _, f := range fields {
+		columns = append(columns,
+			fmt.Sprintf("COALESCE(GROUP_CONCAT(DISTINCT IF(sd.lang = 'en', NULL, %s) SEPARATOR ' '), '') AS %s", f.expr, f.alias),
+			fmt.Sprintf("COALESCE(GROUP_CONCAT(DISTINCT IF(sd.lang = 'en', %s, NULL) SEPARATOR ' '), '') AS en_%s", f.expr, f.alias))
+	}
+
+	return strings.Join(columns, ",\n\t\t\t")
+}()
+
 func (r *Repository) SearchStamps(ctx context.Context, params SearchStampsParams) ([]StampForSearch, error) {
-	baseQuery := `
+	tagNames := []string{"GROUP_CONCAT(DISTINCT t.name SEPARATOR ' ')", "GROUP_CONCAT(DISTINCT ta.name SEPARATOR ' ')"}
+	withClause := ""
+	ancestorJoins := ""
+	if params.IncludeDescendants {
+		// 祖先タグの名前もタグ文字列に含めることで、親タグの名前で子孫タグが付いたスタンプが見つかるようにする
+		tagNames = append(tagNames, "GROUP_CONCAT(DISTINCT at.name SEPARATOR ' ')")
+		withClause = "WITH RECURSIVE " + tagAncestorsCTE
+		ancestorJoins = `
+		LEFT JOIN tag_ancestors anc ON anc.tag_id = t.id
+		LEFT JOIN tags at ON at.id = anc.ancestor_id`
+	}
+	langCondition := ""
+	var args []interface{}
+	if params.Lang != "" {
+		langCondition = " AND sd.lang = ?"
+		args = append(args, params.Lang)
+	}
+	baseQuery := withClause + `
 		SELECT
 			s.id, s.name, s.file_id, s.created_at, s.updated_at, s.count_monthly,
-			COALESCE(GROUP_CONCAT(DISTINCT t.name SEPARATOR ' '), '') AS tags,
-			COALESCE(GROUP_CONCAT(DISTINCT sd.description SEPARATOR ' '), '') AS descriptions
+			CONCAT_WS(' ', ` + strings.Join(tagNames, ", ") + `) AS tags,
+			` + searchDescriptionColumns + `
 		FROM stamps s
-		LEFT JOIN stamp_descriptions sd ON s.id = sd.stamp_id
-		LEFT JOIN stamp_tags st ON s.id = st.stamp_id
-		LEFT JOIN tags t ON st.tag_id = t.id
+		LEFT JOIN stamp_descriptions sd ON s.id = sd.stamp_id AND sd.hidden = FALSE` + langCondition + `
+		LEFT JOIN stamp_tags st ON s.id = st.stamp_id AND st.hidden = FALSE
+		LEFT JOIN tags t ON st.tag_id = t.id AND t.hidden = FALSE
+		LEFT JOIN tag_aliases ta ON ta.tag_id = t.id` + ancestorJoins + `
 	`
 	var whereClauses []string
 	var havingClauses []string
-	var args []interface{}
 
 	if params.CreatedSince != nil {
 		whereClauses = append(whereClauses, "s.created_at >= ?")
@@ -82,6 +138,14 @@ func (r *Repository) SearchStamps(ctx context.Context, params SearchStampsParams
 		whereClauses = append(whereClauses, "s.count_monthly <= ?")
 		args = append(args, *params.CountMonthlyMax)
 	}
+	if params.CategoryID != nil {
+		whereClauses = append(whereClauses, "EXISTS (SELECT 1 FROM stamp_tags cst JOIN tags ct ON cst.tag_id = ct.id WHERE cst.stamp_id = s.id AND cst.hidden = FALSE AND ct.hidden = FALSE AND ct.category_id = ?)")
+		args = append(args, *params.CategoryID)
+	}
+	if params.ActiveSince != nil {
+		whereClauses = append(whereClauses, "(s.created_at >= ? OR EXISTS (SELECT 1 FROM stamp_daily_usages u WHERE u.stamp_id = s.id AND u.date >= ?))")
+		args = append(args, params.ActiveSince, params.ActiveSince)
+	}
 
 	addHavingOrClause := func(query string, field string) {
 		terms := strings.Fields(query)
@@ -98,8 +162,26 @@ func (r *Repository) SearchStamps(ctx context.Context, params SearchStampsParams
 	if params.Name != "" {
 		addHavingOrClause(params.Name, "s.name")
 	}
-	if params.Description != "" {
-		addHavingOrClause(params.Description, "descriptions")
+	// 説明文の検索は構造化された項目も対象にする。英語の説明文は語形が変わっていても見つかるように語幹でも探す
+	const descriptionText = "CONCAT_WS(' ', descriptions, meanings, usages, origins, examples, en_descriptions, en_meanings, en_usages, en_origins, en_examples)"
+	const englishDescriptionText = "CONCAT_WS(' ', en_descriptions, en_meanings, en_usages, en_origins, en_examples)"
+	// englishStemClause は語幹で探す条件を " OR " を付けて返す。語幹は単語の先頭から探す (部分一致にすると use の語幹 us が because や music に一致してしまう)
+	englishStemClause := func(term string) string {
+		pattern := textanalysis.EnglishStemPattern(term)
+		if pattern == "" {
+			return ""
+		}
+		args = append(args, pattern)
+
+		return " OR LOWER(" + englishDescriptionText + ") REGEXP ?"
+	}
+	if terms := strings.Fields(params.Description); len(terms) > 0 {
+		var clauses []string
+		for _, term := range terms {
+			args = append(args, "%"+term+"%")
+			clauses = append(clauses, descriptionText+" LIKE ?"+englishStemClause(term))
+		}
+		havingClauses = append(havingClauses, "("+strings.Join(clauses, " OR ")+")")
 	}
 	if len(params.Tags) > 0 {
 		addHavingOrClause(strings.Join(params.Tags, " "), "tags")
@@ -109,8 +191,9 @@ func (r *Repository) SearchStamps(ctx context.Context, params SearchStampsParams
 		if len(terms) > 0 {
 			var qClauses []string
 			for _, term := range terms {
-				qClauses = append(qClauses, "s.name COLLATE utf8mb4_unicode_ci LIKE ? OR descriptions COLLATE utf8mb4_unicode_ci LIKE ? OR tags COLLATE utf8mb4_unicode_ci LIKE ?")
-				args = append(args, "%"+term+"%", "%"+term+"%", "%"+term+"%")
+				args = append(args, "%"+term+"%", "%"+term+"%")
+				qClauses = append(qClauses, "s.name COLLATE utf8mb4_unicode_ci LIKE ? OR "+descriptionText+" COLLATE utf8mb4_unicode_ci LIKE ?"+englishStemClause(term)+" OR tags COLLATE utf8mb4_unicode_ci LIKE ?")
+				args = append(args, "%"+term+"%")
 			}
 			havingClauses = append(havingClauses, "("+strings.Join(qClauses, " OR ")+")")
 		}
@@ -47,16 +47,38 @@ func (r *Repository) GetStampSummaries(ctx context.Context) ([]*StampSummary, er
 	return stampSummaries, nil
 }
 
-func (r *Repository) GetStampsByTagID(ctx context.Context, tagID uuid.UUID) ([]*Stamp, error) {
+// GetStampsByTagID はタグが付いたスタンプを返す。includeDescendants が true の場合は子孫タグが付いたスタンプも含める。
+// 通報で非表示にされたタグや紐づけは含めない。
+func (r *Repository) GetStampsByTagID(ctx context.Context, tagID uuid.UUID, includeDescendants bool) ([]*Stamp, error) {
+	tagID, err := r.ResolveTagID(ctx, tagID)
+	if err != nil {
+		return nil, err
+	}
+
 	stampsByTagID := []*Stamp{}
 	query := `SELECT
             stamps.id, stamps.name, stamps.file_id, stamps.creator_id,
             stamps.is_unicode, stamps.created_at, stamps.updated_at,
             stamps.count_monthly, stamps.count_total
         FROM stamps
-        INNER JOIN stamp_tags ON stamps.id = stamp_tags.stamp_id
+        INNER JOIN stamp_tags ON stamps.id = stamp_tags.stamp_id AND stamp_tags.hidden = FALSE
+        INNER JOIN tags ON stamp_tags.tag_id = tags.id AND tags.hidden = FALSE
         WHERE stamp_tags.tag_id = ?`
-	if err := r.db.SelectContext(ctx, &stampsByTagID, query, tagID); err != nil {
+	args := []interface{}{tagID}
+	if includeDescendants {
+		query = `WITH RECURSIVE ` + tagAncestorsCTE + `
+        SELECT DISTINCT
+            stamps.id, stamps.name, stamps.file_id, stamps.creator_id,
+            stamps.is_unicode, stamps.created_at, stamps.updated_at,
+            stamps.count_monthly, stamps.count_total
+        FROM stamps
+        INNER JOIN stamp_tags ON stamps.id = stamp_tags.stamp_id AND stamp_tags.hidden = FALSE
+        INNER JOIN tags ON stamp_tags.tag_id = tags.id AND tags.hidden = FALSE
+        WHERE stamp_tags.tag_id = ?
+            OR stamp_tags.tag_id IN (SELECT tag_id FROM tag_ancestors WHERE ancestor_id = ?)`
+		args = append(args, tagID)
+	}
+	if err := r.db.SelectContext(ctx, &stampsByTagID, query, args...); err != nil {
 		return nil, fmt.Errorf("select stamps by tagID: %w", err)
 	}
 
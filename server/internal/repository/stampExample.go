@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StampMessageExample はスタンプの使用例として登録された traQ のメッセージ。本文と投稿者は登録時点のもの
+type StampMessageExample struct {
+	StampID     uuid.UUID `db:"stamp_id" json:"stamp_id"`
+	MessageID   uuid.UUID `db:"message_id" json:"message_id"`
+	ChannelID   uuid.UUID `db:"channel_id" json:"channel_id"`
+	ChannelPath string    `db:"channel_path" json:"channel_path"`
+	AuthorID    uuid.UUID `db:"author_id" json:"author_id"`
+	AuthorName  string    `db:"author_name" json:"author_name"`
+	Content     string    `db:"content" json:"content"`
+	PostedAt    time.Time `db:"posted_at" json:"posted_at"`
+	CreatorID   uuid.UUID `db:"creator_id" json:"creator_id"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+var (
+	ErrStampExampleNotFound      = errors.New("stamp example not found")
+	ErrStampExampleAlreadyExists = errors.New("stamp example already exists")
+)
+
+func (r *Repository) GetStampExamples(ctx context.Context, stampID uuid.UUID) ([]*StampMessageExample, error) {
+	examples := []*StampMessageExample{}
+	if err := r.db.SelectContext(ctx, &examples, `
+		SELECT stamp_id, message_id, channel_id, channel_path, author_id, author_name, content, posted_at, creator_id, created_at
+		FROM stamp_message_examples WHERE stamp_id = ? ORDER BY created_at DESC`, stampID); err != nil {
+		return nil, fmt.Errorf("select stamp examples: %w", err)
+	}
+
+	return examples, nil
+}
+
+func (r *Repository) GetStampExample(ctx context.Context, stampID uuid.UUID, messageID uuid.UUID) (*StampMessageExample, error) {
+	example := &StampMessageExample{}
+	if err := r.db.GetContext(ctx, example, `
+		SELECT stamp_id, message_id, channel_id, channel_path, author_id, author_name, content, posted_at, creator_id, created_at
+		FROM stamp_message_examples WHERE stamp_id = ? AND message_id = ?`, stampID, messageID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrStampExampleNotFound
+		}
+
+		return nil, fmt.Errorf("select stamp example: %w", err)
+	}
+
+	return example, nil
+}
+
+func (r *Repository) CreateStampExample(ctx context.Context, example *StampMessageExample) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO stamp_message_examples
+			(stamp_id, message_id, channel_id, channel_path, author_id, author_name, content, posted_at, creator_id, created_at)
+		VALUES
+			(:stamp_id, :message_id, :channel_id, :channel_path, :author_id, :author_name, :content, :posted_at, :creator_id, :created_at)`,
+		example); err != nil {
+		if isDuplicateEntry(err) {
+			return ErrStampExampleAlreadyExists
+		}
+
+		return fmt.Errorf("insert stamp example: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteStampExample(ctx context.Context, stampID uuid.UUID, messageID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM stamp_message_examples WHERE stamp_id = ? AND message_id = ?", stampID, messageID)
+	if err != nil {
+		return fmt.Errorf("delete stamp example: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrStampExampleNotFound
+	}
+
+	return nil
+}
@@ -23,6 +23,7 @@ func (r *Repository) CreateStampTags(ctx context.Context, params CreateStampTagP
 	if _, err := r.db.ExecContext(ctx, "INSERT INTO stamp_tags (stamp_id, tag_id, creator_id) VALUES (?, ?, ?)", params.StampID, params.TagID, params.CreatorID); err != nil {
 		return fmt.Errorf("failed to insert stampTags:%w", err)
 	}
+	r.invalidateTagGraph()
 
 	return nil
 }
@@ -31,6 +32,7 @@ func (r *Repository) DeleteStampTags(ctx context.Context, stampID uuid.UUID, tag
 	if _, err := r.db.ExecContext(ctx, "DELETE FROM stamp_tags WHERE stamp_id = ? AND tag_id = ?", stampID, tagID); err != nil {
 		return fmt.Errorf("failed to delete stampTag:%w", err)
 	}
+	r.invalidateTagGraph()
 
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,6 +19,25 @@ type (
 	CreateTagParams struct {
 		Name      string    `db:"name" json:"tag_name"`
 		CreatorID uuid.UUID `db:"creator_id" json:"creator_id"`
+		// Status が空の場合は承認済みとして作成する
+		Status string `db:"status" json:"status"`
+	}
+
+	TagWithStats struct {
+		ID         uuid.UUID  `db:"id" json:"tag_id"`
+		Name       string     `db:"name" json:"tag_name"`
+		CreatorID  uuid.UUID  `db:"creator_id" json:"creator_id"`
+		CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+		StampCount int        `db:"stamp_count" json:"count"`
+		LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at"`
+	}
+
+	GetTagsParams struct {
+		Sort     string
+		MinCount int
+		Prefix   string
+		Limit    int
+		Offset   int
 	}
 
 	Tag struct {
@@ -29,19 +49,77 @@ type (
 	}
 )
 
-func (r *Repository) GetTags(ctx context.Context) ([]*TagSummary, error) {
-	tags := []*TagSummary{}
-	if err := r.db.SelectContext(ctx, &tags, "SELECT id,name FROM tags"); err != nil {
-		return nil, fmt.Errorf("select tags: %w", err)
+func (r *Repository) GetTags(ctx context.Context, params GetTagsParams) ([]*TagWithStats, int, error) {
+	query := `
+		SELECT
+			t.id, t.name, t.creator_id, t.created_at,
+			COUNT(st.stamp_id) AS stamp_count, MAX(st.created_at) AS last_used_at
+		FROM tags t
+		LEFT JOIN stamp_tags st ON st.tag_id = t.id AND st.hidden = FALSE
+		WHERE t.status = ? AND t.hidden = FALSE`
+	args := []interface{}{TagStatusApproved}
+	if params.Prefix != "" {
+		query += " AND t.name LIKE ?"
+		args = append(args, escapeLike(params.Prefix)+"%")
+	}
+	query += " GROUP BY t.id"
+	if params.MinCount > 0 {
+		query += " HAVING stamp_count >= ?"
+		args = append(args, params.MinCount)
 	}
 
-	return tags, nil
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM ("+query+") AS filtered", args...); err != nil {
+		return nil, 0, fmt.Errorf("count tags: %w", err)
+	}
+
+	switch params.Sort {
+	case "count":
+		query += " ORDER BY stamp_count DESC, t.name ASC"
+	case "created_at":
+		query += " ORDER BY t.created_at DESC, t.name ASC"
+	default:
+		query += " ORDER BY t.name ASC"
+	}
+	switch {
+	case params.Limit > 0:
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, params.Limit, params.Offset)
+	case params.Offset > 0:
+		// OFFSET だけは指定できないので、件数は上限なしにする
+		query += " LIMIT 18446744073709551615 OFFSET ?"
+		args = append(args, params.Offset)
+	}
+
+	tags := []*TagWithStats{}
+	if err := r.db.SelectContext(ctx, &tags, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("select tags: %w", err)
+	}
+
+	return tags, total, nil
+}
+
+// escapeLike は LIKE のパターンで特別な意味を持つ文字をエスケープする
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
 }
 
 func (r *Repository) UpdateTags(ctx context.Context, tagID uuid.UUID, name string) error {
+	isAlias, err := r.isTagAlias(ctx, name)
+	if err != nil {
+		return err
+	}
+	if isAlias {
+		return ErrTagConflict
+	}
 	if _, err := r.db.ExecContext(ctx, `UPDATE tags SET name = ? WHERE id = ?`, name, tagID); err != nil {
+		if isDuplicateEntry(err) {
+			return ErrTagConflict
+		}
+
 		return fmt.Errorf("failed to update tag: %w", err)
 	}
+	r.invalidateTagGraph()
 
 	return nil
 }
@@ -50,23 +128,40 @@ func (r *Repository) DeleteTags(ctx context.Context, tagID uuid.UUID) error {
 	if _, err := r.db.ExecContext(ctx, `DELETE FROM tags WHERE id=?`, tagID); err != nil {
 		return fmt.Errorf("failed to delete tag: %w", err)
 	}
+	r.invalidateTagGraph()
 
 	return nil
 }
 
 func (r *Repository) CreateTags(ctx context.Context, params CreateTagParams) (uuid.UUID, error) {
+	isAlias, err := r.isTagAlias(ctx, params.Name)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if isAlias {
+		return uuid.Nil, ErrTagConflict
+	}
 	tagID, _ := uuid.NewV7()
 	now := time.Now()
-	if _, err := r.db.ExecContext(ctx, "INSERT INTO tags(id, name, creator_id, created_at, updated_at) VALUES(?,?,?,?,?)", tagID, params.Name, params.CreatorID, now, now); err != nil {
+	status := params.Status
+	if status == "" {
+		status = TagStatusApproved
+	}
+	if _, err := r.db.ExecContext(ctx, "INSERT INTO tags(id, name, creator_id, created_at, updated_at, status) VALUES(?,?,?,?,?,?)", tagID, params.Name, params.CreatorID, now, now, status); err != nil {
+		if isDuplicateEntry(err) {
+			return uuid.Nil, ErrTagConflict
+		}
+
 		return uuid.Nil, fmt.Errorf("failed to insert tag: %w", err)
 	}
+	r.invalidateTagGraph()
 
 	return tagID, nil
 }
 
 func (r *Repository) GetTagsByStampID(ctx context.Context, stampID uuid.UUID) ([]*TagSummary, error) {
 	tagsummaries := []*TagSummary{}
-	if err := r.db.SelectContext(ctx, &tagsummaries, "SELECT tags.id, tags.name FROM tags JOIN stamp_tags ON stamp_tags.tag_id = tags.id WHERE stamp_tags.stamp_id = ?", stampID); err != nil {
+	if err := r.db.SelectContext(ctx, &tagsummaries, "SELECT tags.id, tags.name FROM tags JOIN stamp_tags ON stamp_tags.tag_id = tags.id WHERE stamp_tags.stamp_id = ? AND stamp_tags.hidden = FALSE AND tags.hidden = FALSE", stampID); err != nil {
 		return nil, fmt.Errorf("select tags by stampID: %w", err)
 	}
 
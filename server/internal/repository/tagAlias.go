@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrTagMergeIntoSelf = errors.New("cannot merge a tag into itself")
+
+// MergeTags は source のタグ付けをすべて target に移し、source を削除して旧名を target の別名として残す。
+// target に既に付いているスタンプへのタグ付けは重複させない。
+func (r *Repository) MergeTags(ctx context.Context, sourceID uuid.UUID, targetID uuid.UUID, userID uuid.UUID) error {
+	if sourceID == targetID {
+		return ErrTagMergeIntoSelf
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source TagNode
+	if err := tx.GetContext(ctx, &source, "SELECT id, name, parent_id FROM tags WHERE id = ? FOR UPDATE", sourceID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTagNotFound
+		}
+
+		return fmt.Errorf("select source tag: %w", err)
+	}
+	var targetCount int
+	if err := tx.GetContext(ctx, &targetCount, "SELECT COUNT(*) FROM tags WHERE id = ? FOR UPDATE", targetID); err != nil {
+		return fmt.Errorf("select target tag: %w", err)
+	}
+	if targetCount == 0 {
+		return ErrTagNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT IGNORE INTO stamp_tags (stamp_id, tag_id, creator_id, created_at)
+		SELECT stamp_id, ?, creator_id, created_at FROM stamp_tags WHERE tag_id = ?`,
+		targetID, sourceID); err != nil {
+		return fmt.Errorf("move stamp_tags: %w", err)
+	}
+	// source の子タグを target の子にする。target が source の子孫の場合は循環しないよう先に target を source の親に付け替える
+	isDescendant, err := isTagDescendant(ctx, tx, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+	if isDescendant {
+		if _, err := tx.ExecContext(ctx, "UPDATE tags SET parent_id = ? WHERE id = ?", source.ParentID, targetID); err != nil {
+			return fmt.Errorf("detach target tag: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE tags SET parent_id = ? WHERE parent_id = ? AND id <> ?", targetID, sourceID, targetID); err != nil {
+		return fmt.Errorf("move child tags: %w", err)
+	}
+	// source の別名も target に付け替える (tags の削除で CASCADE されないように先に行う)
+	if _, err := tx.ExecContext(ctx, "UPDATE tag_aliases SET tag_id = ? WHERE tag_id = ?", targetID, sourceID); err != nil {
+		return fmt.Errorf("move tag aliases: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id = ?", sourceID); err != nil {
+		return fmt.Errorf("delete source tag: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO tag_aliases (name, tag_id, source_tag_id, creator_id, created_at) VALUES (?, ?, ?, ?, ?)`,
+		source.Name, targetID, sourceID, userID, time.Now()); err != nil {
+		return fmt.Errorf("insert tag alias: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	r.invalidateTagGraph()
+
+	return nil
+}
+
+// ResolveTagID は統合済みのタグIDを統合先のタグIDに解決する。統合されていなければそのまま返す。
+func (r *Repository) ResolveTagID(ctx context.Context, tagID uuid.UUID) (uuid.UUID, error) {
+	var resolved uuid.UUID
+	if err := r.db.GetContext(ctx, &resolved, "SELECT tag_id FROM tag_aliases WHERE source_tag_id = ?", tagID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return tagID, nil
+		}
+
+		return uuid.Nil, fmt.Errorf("resolve tag id: %w", err)
+	}
+
+	return resolved, nil
+}
+
+// GetTagByName はタグ名または別名からタグを返す
+func (r *Repository) GetTagByName(ctx context.Context, name string) (*TagSummary, error) {
+	tag := &TagSummary{}
+	err := r.db.GetContext(ctx, tag, `
+		SELECT id, name FROM tags WHERE name = ?
+		UNION ALL
+		SELECT t.id, t.name FROM tag_aliases a JOIN tags t ON t.id = a.tag_id WHERE a.name = ?
+		LIMIT 1`, name, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTagNotFound
+		}
+
+		return nil, fmt.Errorf("select tag by name: %w", err)
+	}
+
+	return tag, nil
+}
+
+func (r *Repository) GetTagAliases(ctx context.Context, tagID uuid.UUID) ([]string, error) {
+	aliases := []string{}
+	if err := r.db.SelectContext(ctx, &aliases, "SELECT name FROM tag_aliases WHERE tag_id = ? ORDER BY name", tagID); err != nil {
+		return nil, fmt.Errorf("select tag aliases: %w", err)
+	}
+
+	return aliases, nil
+}
+
+func (r *Repository) isTagAlias(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM tag_aliases WHERE name = ?)", name); err != nil {
+		return false, fmt.Errorf("check tag alias: %w", err)
+	}
+
+	return exists, nil
+}
+
+// TagNameEntry はタグ名または別名 (Name) と、それが指すタグ
+type TagNameEntry struct {
+	Name    string    `db:"name"`
+	TagID   uuid.UUID `db:"tag_id"`
+	TagName string    `db:"tag_name"`
+}
+
+// GetTagNameEntries はすべてのタグ名と別名を返す
+func (r *Repository) GetTagNameEntries(ctx context.Context) ([]TagNameEntry, error) {
+	entries := []TagNameEntry{}
+	if err := r.db.SelectContext(ctx, &entries, `
+		SELECT name, id AS tag_id, name AS tag_name FROM tags
+		UNION ALL
+		SELECT a.name, t.id AS tag_id, t.name AS tag_name FROM tag_aliases a JOIN tags t ON t.id = a.tag_id`); err != nil {
+		return nil, fmt.Errorf("select tag names: %w", err)
+	}
+
+	return entries, nil
+}
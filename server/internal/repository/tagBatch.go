@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	TagBatchActionAdd    = "add"
+	TagBatchActionRemove = "remove"
+)
+
+// 一括操作のスタンプごとの結果
+const (
+	TagBatchStatusAdded         = "added"
+	TagBatchStatusAlreadyLinked = "already_linked"
+	TagBatchStatusRemoved       = "removed"
+	TagBatchStatusNotLinked     = "not_linked"
+	TagBatchStatusStampNotFound = "stamp_not_found"
+)
+
+type (
+	TagBatchOperation struct {
+		ID        uuid.UUID  `db:"id" json:"operation_id"`
+		TagID     uuid.UUID  `db:"tag_id" json:"tag_id"`
+		Action    string     `db:"action" json:"action"`
+		CreatorID uuid.UUID  `db:"creator_id" json:"creator_id"`
+		CreatedAt time.Time  `db:"created_at" json:"created_at"`
+		UndoneAt  *time.Time `db:"undone_at" json:"undone_at"`
+	}
+
+	TagBatchItemResult struct {
+		StampID uuid.UUID `json:"stamp_id"`
+		Status  string    `json:"status"`
+	}
+
+	tagBatchItem struct {
+		OperationID   uuid.UUID `db:"operation_id"`
+		StampID       uuid.UUID `db:"stamp_id"`
+		TagID         uuid.UUID `db:"tag_id"`
+		LinkCreatorID uuid.UUID `db:"link_creator_id"`
+		// LinkCreatedAt はタグ付けの日時 (日時を記録する前からあるタグ付けでは nil)
+		LinkCreatedAt *time.Time `db:"link_created_at"`
+	}
+)
+
+var (
+	ErrTagBatchOperationNotFound = errors.New("tag batch operation not found")
+	ErrTagBatchOperationUndone   = errors.New("tag batch operation has already been undone")
+)
+
+// BatchUpdateStampTags は stampIDs のスタンプにタグを一括で付与 (action = add) または削除 (action = remove) し、
+// 取り消し用に操作を記録する。スタンプごとの結果を stampIDs の順 (重複は除く) で返す。
+func (r *Repository) BatchUpdateStampTags(ctx context.Context, tagID uuid.UUID, action string, stampIDs []uuid.UUID, userID uuid.UUID) (*TagBatchOperation, []TagBatchItemResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM tags WHERE id = ? FOR UPDATE", tagID); err != nil {
+		return nil, nil, fmt.Errorf("select tag: %w", err)
+	}
+	if count == 0 {
+		return nil, nil, ErrTagNotFound
+	}
+
+	existingStamps := map[uuid.UUID]bool{}
+	links := map[uuid.UUID]tagBatchItem{}
+	if len(stampIDs) > 0 {
+		query, args, err := sqlx.In("SELECT id FROM stamps WHERE id IN (?)", stampIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("build stamps query: %w", err)
+		}
+		ids := []uuid.UUID{}
+		if err := tx.SelectContext(ctx, &ids, tx.Rebind(query), args...); err != nil {
+			return nil, nil, fmt.Errorf("select stamps: %w", err)
+		}
+		for _, id := range ids {
+			existingStamps[id] = true
+		}
+
+		query, args, err = sqlx.In(`
+			SELECT stamp_id, tag_id, creator_id AS link_creator_id, created_at AS link_created_at
+			FROM stamp_tags WHERE tag_id = ? AND stamp_id IN (?) FOR UPDATE`, tagID, stampIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("build stamp_tags query: %w", err)
+		}
+		linked := []tagBatchItem{}
+		if err := tx.SelectContext(ctx, &linked, tx.Rebind(query), args...); err != nil {
+			return nil, nil, fmt.Errorf("select stamp_tags: %w", err)
+		}
+		for _, l := range linked {
+			links[l.StampID] = l
+		}
+	}
+
+	operationID, _ := uuid.NewV7()
+	now := time.Now()
+	operation := &TagBatchOperation{ID: operationID, TagID: tagID, Action: action, CreatorID: userID, CreatedAt: now}
+
+	results := make([]TagBatchItemResult, 0, len(stampIDs))
+	items := []tagBatchItem{}
+	seen := map[uuid.UUID]bool{}
+	for _, stampID := range stampIDs {
+		if seen[stampID] {
+			continue
+		}
+		seen[stampID] = true
+
+		link, isLinked := links[stampID]
+		var status string
+		switch {
+		case !existingStamps[stampID]:
+			status = TagBatchStatusStampNotFound
+		case action == TagBatchActionAdd && isLinked:
+			status = TagBatchStatusAlreadyLinked
+		case action == TagBatchActionAdd:
+			status = TagBatchStatusAdded
+			items = append(items, tagBatchItem{StampID: stampID, TagID: tagID, LinkCreatorID: userID, LinkCreatedAt: &now})
+		case isLinked:
+			status = TagBatchStatusRemoved
+			items = append(items, link)
+		default:
+			status = TagBatchStatusNotLinked
+		}
+		results = append(results, TagBatchItemResult{StampID: stampID, Status: status})
+	}
+
+	if _, err := tx.NamedExecContext(ctx, `
+		INSERT INTO tag_batch_operations (id, tag_id, action, creator_id, created_at)
+		VALUES (:id, :tag_id, :action, :creator_id, :created_at)`, operation); err != nil {
+		return nil, nil, fmt.Errorf("insert tag batch operation: %w", err)
+	}
+	if len(items) > 0 {
+		for i := range items {
+			items[i].OperationID = operationID
+		}
+		if _, err := tx.NamedExecContext(ctx, `
+			INSERT INTO tag_batch_operation_items (operation_id, stamp_id, link_creator_id, link_created_at)
+			VALUES (:operation_id, :stamp_id, :link_creator_id, :link_created_at)`, items); err != nil {
+			return nil, nil, fmt.Errorf("insert tag batch operation items: %w", err)
+		}
+
+		if action == TagBatchActionAdd {
+			if _, err := tx.NamedExecContext(ctx, `
+				INSERT INTO stamp_tags (stamp_id, tag_id, creator_id, created_at)
+				VALUES (:stamp_id, :tag_id, :link_creator_id, :link_created_at)`, items); err != nil {
+				return nil, nil, fmt.Errorf("insert stamp_tags: %w", err)
+			}
+		} else {
+			if _, err := tx.ExecContext(ctx, `
+				DELETE st FROM stamp_tags st
+				JOIN tag_batch_operation_items i ON i.stamp_id = st.stamp_id
+				WHERE i.operation_id = ? AND st.tag_id = ?`, operationID, tagID); err != nil {
+				return nil, nil, fmt.Errorf("delete stamp_tags: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	r.invalidateTagGraph()
+
+	return operation, results, nil
+}
+
+func (r *Repository) GetTagBatchOperation(ctx context.Context, operationID uuid.UUID) (*TagBatchOperation, error) {
+	operation := &TagBatchOperation{}
+	if err := r.db.GetContext(ctx, operation, "SELECT id, tag_id, action, creator_id, created_at, undone_at FROM tag_batch_operations WHERE id = ?", operationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTagBatchOperationNotFound
+		}
+
+		return nil, fmt.Errorf("select tag batch operation: %w", err)
+	}
+
+	return operation, nil
+}
+
+// UndoTagBatchOperation は一括操作で変更したタグ付けを元に戻す。
+// 付与を取り消す場合は、その操作で付けたタグ付けがまだ残っているものだけを削除する。
+func (r *Repository) UndoTagBatchOperation(ctx context.Context, operationID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	operation := TagBatchOperation{}
+	if err := tx.GetContext(ctx, &operation, "SELECT id, tag_id, action, creator_id, created_at, undone_at FROM tag_batch_operations WHERE id = ? FOR UPDATE", operationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTagBatchOperationNotFound
+		}
+
+		return fmt.Errorf("select tag batch operation: %w", err)
+	}
+	if operation.UndoneAt != nil {
+		return ErrTagBatchOperationUndone
+	}
+
+	if operation.Action == TagBatchActionAdd {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE st FROM stamp_tags st
+			JOIN tag_batch_operation_items i
+				ON i.stamp_id = st.stamp_id AND i.link_creator_id = st.creator_id AND i.link_created_at <=> st.created_at
+			WHERE i.operation_id = ? AND st.tag_id = ?`, operationID, operation.TagID); err != nil {
+			return fmt.Errorf("delete stamp_tags: %w", err)
+		}
+	} else {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT IGNORE INTO stamp_tags (stamp_id, tag_id, creator_id, created_at)
+			SELECT stamp_id, ?, link_creator_id, link_created_at FROM tag_batch_operation_items WHERE operation_id = ?`,
+			operation.TagID, operationID); err != nil {
+			return fmt.Errorf("restore stamp_tags: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE tag_batch_operations SET undone_at = ? WHERE id = ?", time.Now(), operationID); err != nil {
+		return fmt.Errorf("update tag batch operation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	r.invalidateTagGraph()
+
+	return nil
+}
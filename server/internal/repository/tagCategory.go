@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+)
+
+type (
+	TagCategory struct {
+		ID           uuid.UUID `db:"id" json:"category_id"`
+		Name         string    `db:"name" json:"category_name"`
+		Color        string    `db:"color" json:"color"`
+		DisplayOrder int       `db:"display_order" json:"display_order"`
+		CreatedAt    time.Time `db:"created_at" json:"created_at"`
+		UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	}
+
+	TagCategoryParams struct {
+		Name         string
+		Color        string
+		DisplayOrder int
+	}
+
+	CategorizedTag struct {
+		ID                   uuid.UUID  `db:"id"`
+		Name                 string     `db:"name"`
+		CategoryID           *uuid.UUID `db:"category_id"`
+		CategoryName         *string    `db:"category_name"`
+		CategoryColor        *string    `db:"category_color"`
+		CategoryDisplayOrder *int       `db:"category_display_order"`
+	}
+)
+
+var (
+	ErrTagCategoryNotFound = errors.New("tag category not found")
+	ErrTagCategoryConflict = errors.New("tag category with this name already exists")
+)
+
+// mysqlErrDuplicateEntry は UNIQUE 制約違反のエラー番号
+const mysqlErrDuplicateEntry = 1062
+
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
+
+func (r *Repository) GetTagCategories(ctx context.Context) ([]*TagCategory, error) {
+	categories := []*TagCategory{}
+	if err := r.db.SelectContext(ctx, &categories, "SELECT id, name, color, display_order, created_at, updated_at FROM tag_categories ORDER BY display_order, name"); err != nil {
+		return nil, fmt.Errorf("select tag categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (r *Repository) CreateTagCategory(ctx context.Context, params TagCategoryParams) (*TagCategory, error) {
+	id, _ := uuid.NewV7()
+	now := time.Now()
+	category := &TagCategory{
+		ID:           id,
+		Name:         params.Name,
+		Color:        params.Color,
+		DisplayOrder: params.DisplayOrder,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO tag_categories (id, name, color, display_order, created_at, updated_at)
+		VALUES (:id, :name, :color, :display_order, :created_at, :updated_at)`, category); err != nil {
+		if isDuplicateEntry(err) {
+			return nil, ErrTagCategoryConflict
+		}
+
+		return nil, fmt.Errorf("failed to insert tag category: %w", err)
+	}
+
+	return category, nil
+}
+
+func (r *Repository) UpdateTagCategory(ctx context.Context, categoryID uuid.UUID, params TagCategoryParams) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE tag_categories SET name = ?, color = ?, display_order = ?, updated_at = ? WHERE id = ?",
+		params.Name, params.Color, params.DisplayOrder, time.Now(), categoryID)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return ErrTagCategoryConflict
+		}
+
+		return fmt.Errorf("failed to update tag category: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrTagCategoryNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) DeleteTagCategory(ctx context.Context, categoryID uuid.UUID) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM tag_categories WHERE id = ?", categoryID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag category: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrTagCategoryNotFound
+	}
+
+	return nil
+}
+
+// SetTagCategory はタグのカテゴリを設定する。categoryID が nil の場合はカテゴリなしにする。
+func (r *Repository) SetTagCategory(ctx context.Context, tagID uuid.UUID, categoryID *uuid.UUID) error {
+	if categoryID != nil {
+		var count int
+		if err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM tag_categories WHERE id = ?", *categoryID); err != nil {
+			return fmt.Errorf("select tag category: %w", err)
+		}
+		if count == 0 {
+			return ErrTagCategoryNotFound
+		}
+	}
+
+	var count int
+	if err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM tags WHERE id = ?", tagID); err != nil {
+		return fmt.Errorf("select tag: %w", err)
+	}
+	if count == 0 {
+		return ErrTagNotFound
+	}
+
+	if _, err := r.db.ExecContext(ctx, "UPDATE tags SET category_id = ? WHERE id = ?", categoryID, tagID); err != nil {
+		return fmt.Errorf("failed to update tag category: %w", err)
+	}
+
+	return nil
+}
+
+// GetCategorizedTagsByStampID はスタンプに付いたタグをカテゴリの情報と合わせて、カテゴリの表示順に返す
+func (r *Repository) GetCategorizedTagsByStampID(ctx context.Context, stampID uuid.UUID) ([]*CategorizedTag, error) {
+	tags := []*CategorizedTag{}
+	if err := r.db.SelectContext(ctx, &tags, `
+		SELECT
+			t.id, t.name, c.id AS category_id, c.name AS category_name,
+			c.color AS category_color, c.display_order AS category_display_order
+		FROM tags t
+		JOIN stamp_tags st ON st.tag_id = t.id
+		LEFT JOIN tag_categories c ON c.id = t.category_id
+		WHERE st.stamp_id = ? AND st.hidden = FALSE AND t.hidden = FALSE
+		ORDER BY c.id IS NULL, c.display_order, c.name, t.name`, stampID); err != nil {
+		return nil, fmt.Errorf("select categorized tags by stampID: %w", err)
+	}
+
+	return tags, nil
+}
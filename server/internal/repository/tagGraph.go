@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	TagGraphNode struct {
+		ID    uuid.UUID `db:"id"`
+		Name  string    `db:"name"`
+		Count int       `db:"count"`
+	}
+
+	// TagGraphEdge は同じスタンプに付いているタグの組 (SourceID < TargetID) とそのスタンプ数
+	TagGraphEdge struct {
+		SourceID uuid.UUID `db:"source_id"`
+		TargetID uuid.UUID `db:"target_id"`
+		Count    int       `db:"count"`
+	}
+
+	// TagGraph はタグの共起関係。StampCount はタグが1つ以上付いているスタンプの数
+	TagGraph struct {
+		StampCount int
+		Nodes      []TagGraphNode
+		Edges      []TagGraphEdge
+		ComputedAt time.Time
+	}
+
+	// tagGraphCache は stamp_tags から集計した TagGraph を、タグやタグ付けが変更されるまで保持する
+	tagGraphCache struct {
+		mu         sync.Mutex
+		graph      *TagGraph
+		generation uint64
+	}
+)
+
+// GetTagGraph はタグの共起関係を返す。キャッシュがあればそれを返す。
+// 通報で非表示にされたタグやタグ付け、承認されていない提案中のタグは含めない。
+func (r *Repository) GetTagGraph(ctx context.Context) (*TagGraph, error) {
+	r.tagGraph.mu.Lock()
+	graph, generation := r.tagGraph.graph, r.tagGraph.generation
+	r.tagGraph.mu.Unlock()
+	if graph != nil {
+		return graph, nil
+	}
+
+	graph = &TagGraph{Nodes: []TagGraphNode{}, Edges: []TagGraphEdge{}, ComputedAt: time.Now()}
+	if err := r.db.GetContext(ctx, &graph.StampCount, `
+		SELECT COUNT(DISTINCT st.stamp_id)
+		FROM stamp_tags st
+		JOIN tags t ON t.id = st.tag_id
+		WHERE st.hidden = FALSE AND t.hidden = FALSE AND t.status = ?`, TagStatusApproved); err != nil {
+		return nil, fmt.Errorf("count tagged stamps: %w", err)
+	}
+	if err := r.db.SelectContext(ctx, &graph.Nodes, `
+		SELECT t.id, t.name, COUNT(st.stamp_id) AS count
+		FROM tags t
+		LEFT JOIN stamp_tags st ON st.tag_id = t.id AND st.hidden = FALSE
+		WHERE t.hidden = FALSE AND t.status = ?
+		GROUP BY t.id, t.name
+		ORDER BY t.name`, TagStatusApproved); err != nil {
+		return nil, fmt.Errorf("select tag graph nodes: %w", err)
+	}
+	if err := r.db.SelectContext(ctx, &graph.Edges, `
+		SELECT a.tag_id AS source_id, b.tag_id AS target_id, COUNT(*) AS count
+		FROM stamp_tags a
+		JOIN stamp_tags b ON b.stamp_id = a.stamp_id AND a.tag_id < b.tag_id
+		JOIN tags ta ON ta.id = a.tag_id
+		JOIN tags tb ON tb.id = b.tag_id
+		WHERE a.hidden = FALSE AND b.hidden = FALSE AND ta.hidden = FALSE AND tb.hidden = FALSE
+			AND ta.status = ? AND tb.status = ?
+		GROUP BY a.tag_id, b.tag_id
+		ORDER BY count DESC, a.tag_id, b.tag_id`, TagStatusApproved, TagStatusApproved); err != nil {
+		return nil, fmt.Errorf("select tag graph edges: %w", err)
+	}
+
+	r.tagGraph.mu.Lock()
+	// 集計中に変更があった場合は古い結果をキャッシュしない
+	if r.tagGraph.generation == generation {
+		r.tagGraph.graph = graph
+	}
+	r.tagGraph.mu.Unlock()
+
+	return graph, nil
+}
+
+// invalidateTagGraph はタグやタグ付けの変更後に呼び出し、共起関係のキャッシュを破棄する
+func (r *Repository) invalidateTagGraph() {
+	r.tagGraph.mu.Lock()
+	r.tagGraph.graph = nil
+	r.tagGraph.generation++
+	r.tagGraph.mu.Unlock()
+}
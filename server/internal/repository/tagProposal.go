@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	TagStatusProposed = "proposed"
+	TagStatusApproved = "approved"
+)
+
+type (
+	TagProposal struct {
+		ID         uuid.UUID `db:"id" json:"tag_id"`
+		Name       string    `db:"name" json:"tag_name"`
+		CreatorID  uuid.UUID `db:"creator_id" json:"creator_id"`
+		CreatedAt  time.Time `db:"created_at" json:"created_at"`
+		StampCount int       `db:"stamp_count" json:"count"`
+		UserCount  int       `db:"user_count" json:"user_count"`
+	}
+
+	tagStatusRow struct {
+		ID        uuid.UUID `db:"id"`
+		Name      string    `db:"name"`
+		CreatorID uuid.UUID `db:"creator_id"`
+		Status    string    `db:"status"`
+	}
+)
+
+var ErrTagNotProposed = errors.New("tag is not a proposal")
+
+// GetTagProposals は提案中のタグを、付けたスタンプ数・ユーザー数とともに古い順に返す
+func (r *Repository) GetTagProposals(ctx context.Context) ([]*TagProposal, error) {
+	proposals := []*TagProposal{}
+	if err := r.db.SelectContext(ctx, &proposals, `
+		SELECT
+			t.id, t.name, t.creator_id, t.created_at,
+			COUNT(st.stamp_id) AS stamp_count, COUNT(DISTINCT st.creator_id) AS user_count
+		FROM tags t
+		LEFT JOIN stamp_tags st ON st.tag_id = t.id
+		WHERE t.status = ?
+		GROUP BY t.id
+		ORDER BY t.created_at, t.name`, TagStatusProposed); err != nil {
+		return nil, fmt.Errorf("select tag proposals: %w", err)
+	}
+
+	return proposals, nil
+}
+
+func lockProposedTag(ctx context.Context, tx *sqlx.Tx, tagID uuid.UUID) (*tagStatusRow, error) {
+	tag := &tagStatusRow{}
+	if err := tx.GetContext(ctx, tag, "SELECT id, name, creator_id, status FROM tags WHERE id = ? FOR UPDATE", tagID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTagNotFound
+		}
+
+		return nil, fmt.Errorf("select tag: %w", err)
+	}
+	if tag.Status != TagStatusProposed {
+		return nil, ErrTagNotProposed
+	}
+
+	return tag, nil
+}
+
+// ApproveTag は提案中のタグを承認し、作成者に通知する
+func (r *Repository) ApproveTag(ctx context.Context, tagID uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tag, err := lockProposedTag(ctx, tx, tagID)
+	if err != nil {
+		return err
+	}
+	if err := approveTag(ctx, tx, tag, "タグが管理者に承認されました"); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	r.invalidateTagGraph()
+
+	return nil
+}
+
+// RejectTag は提案中のタグを削除し、作成者に理由を通知する
+func (r *Repository) RejectTag(ctx context.Context, tagID uuid.UUID, reason string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tag, err := lockProposedTag(ctx, tx, tagID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tags WHERE id = ?", tagID); err != nil {
+		return fmt.Errorf("delete tag: %w", err)
+	}
+	message := "タグが却下されました"
+	if reason != "" {
+		message += ": " + reason
+	}
+	if err := insertNotification(ctx, tx, &Notification{
+		UserID:      tag.CreatorID,
+		Type:        NotificationTypeTagRejected,
+		SubjectID:   &tag.ID,
+		SubjectName: tag.Name,
+		Message:     message,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	r.invalidateTagGraph()
+
+	return nil
+}
+
+// ApproveTagIfWidelyUsed は提案中のタグを付けたユーザーが threshold 人以上になっていれば承認し、承認したかを返す
+func (r *Repository) ApproveTagIfWidelyUsed(ctx context.Context, tagID uuid.UUID, threshold int) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	tag, err := lockProposedTag(ctx, tx, tagID)
+	if err != nil {
+		if errors.Is(err, ErrTagNotProposed) || errors.Is(err, ErrTagNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+	var userCount int
+	if err := tx.GetContext(ctx, &userCount, "SELECT COUNT(DISTINCT creator_id) FROM stamp_tags WHERE tag_id = ?", tagID); err != nil {
+		return false, fmt.Errorf("count tag users: %w", err)
+	}
+	if userCount < threshold {
+		return false, nil
+	}
+	if err := approveTag(ctx, tx, tag, fmt.Sprintf("%d人がスタンプに付けたため、タグが承認されました", userCount)); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+	r.invalidateTagGraph()
+
+	return true, nil
+}
+
+func approveTag(ctx context.Context, tx *sqlx.Tx, tag *tagStatusRow, message string) error {
+	if _, err := tx.ExecContext(ctx, "UPDATE tags SET status = ? WHERE id = ?", TagStatusApproved, tag.ID); err != nil {
+		return fmt.Errorf("approve tag: %w", err)
+	}
+
+	return insertNotification(ctx, tx, &Notification{
+		UserID:      tag.CreatorID,
+		Type:        NotificationTypeTagApproved,
+		SubjectID:   &tag.ID,
+		SubjectName: tag.Name,
+		Message:     message,
+	})
+}
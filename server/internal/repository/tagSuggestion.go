@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// relatedStampLimit は名前や説明文が似ているスタンプとして集計するスタンプ数の上限
+const relatedStampLimit = 200
+
+type RelatedStampTag struct {
+	ID    uuid.UUID `db:"id"`
+	Name  string    `db:"name"`
+	Count int       `db:"count"`
+}
+
+// GetTagsOnRelatedStamps は名前か説明文に keywords のいずれかを含むタグ付き済みのスタンプを関連スタンプとし、
+// それらに付いているタグと付いているスタンプ数、および関連スタンプの数を返す。stampID に付いているタグは除く。
+func (r *Repository) GetTagsOnRelatedStamps(ctx context.Context, stampID uuid.UUID, keywords []string) ([]RelatedStampTag, int, error) {
+	if len(keywords) == 0 {
+		return []RelatedStampTag{}, 0, nil
+	}
+
+	conditions := make([]string, 0, len(keywords))
+	args := []any{stampID}
+	for _, k := range keywords {
+		conditions = append(conditions, "s.name LIKE ? OR d.description LIKE ?")
+		pattern := "%" + escapeLike(k) + "%"
+		args = append(args, pattern, pattern)
+	}
+	args = append(args, relatedStampLimit)
+
+	relatedIDs := []uuid.UUID{}
+	if err := r.db.SelectContext(ctx, &relatedIDs, `
+		SELECT DISTINCT s.id FROM stamps s
+		LEFT JOIN stamp_descriptions d ON d.stamp_id = s.id
+		WHERE s.id <> ?
+			AND (`+strings.Join(conditions, " OR ")+`)
+			AND EXISTS (SELECT 1 FROM stamp_tags st WHERE st.stamp_id = s.id)
+		LIMIT ?`, args...); err != nil {
+		return nil, 0, fmt.Errorf("select related stamps: %w", err)
+	}
+	if len(relatedIDs) == 0 {
+		return []RelatedStampTag{}, 0, nil
+	}
+
+	query, inArgs, err := sqlx.In(`
+		SELECT t.id, t.name, COUNT(*) AS count
+		FROM stamp_tags st
+		JOIN tags t ON t.id = st.tag_id
+		WHERE st.stamp_id IN (?) AND st.hidden = FALSE AND t.hidden = FALSE
+			AND st.tag_id NOT IN (SELECT tag_id FROM stamp_tags WHERE stamp_id = ?)
+		GROUP BY t.id, t.name
+		ORDER BY count DESC, t.name`, relatedIDs, stampID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("build related stamp tags query: %w", err)
+	}
+	tags := []RelatedStampTag{}
+	if err := r.db.SelectContext(ctx, &tags, r.db.Rebind(query), inArgs...); err != nil {
+		return nil, 0, fmt.Errorf("select related stamp tags: %w", err)
+	}
+
+	return tags, len(relatedIDs), nil
+}
+
+// GetRecentTagsByUser はユーザーが最近スタンプに付けたタグを新しい順に返す
+func (r *Repository) GetRecentTagsByUser(ctx context.Context, userID uuid.UUID, limit int) ([]*TagSummary, error) {
+	tags := []*TagSummary{}
+	if err := r.db.SelectContext(ctx, &tags, `
+		SELECT t.id, t.name
+		FROM stamp_tags st
+		JOIN tags t ON t.id = st.tag_id
+		WHERE st.creator_id = ? AND t.hidden = FALSE
+		GROUP BY t.id, t.name
+		ORDER BY MAX(st.created_at) DESC
+		LIMIT ?`, userID, limit); err != nil {
+		return nil, fmt.Errorf("select recent tags by user: %w", err)
+	}
+
+	return tags, nil
+}
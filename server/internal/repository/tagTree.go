@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type TagNode struct {
+	ID       uuid.UUID  `db:"id"`
+	Name     string     `db:"name"`
+	ParentID *uuid.UUID `db:"parent_id"`
+}
+
+var ErrTagCycle = errors.New("tag hierarchy would contain a cycle")
+
+// tagAncestorsCTE は各タグとその祖先タグの組 (tag_id, ancestor_id) を列挙する。
+// 親子関係は SetTagParent で循環しないことが保証されている。
+const tagAncestorsCTE = `tag_ancestors (tag_id, ancestor_id) AS (
+	SELECT id, parent_id FROM tags WHERE parent_id IS NOT NULL
+	UNION ALL
+	SELECT a.tag_id, t.parent_id FROM tag_ancestors a JOIN tags t ON t.id = a.ancestor_id
+	WHERE t.parent_id IS NOT NULL
+)`
+
+func (r *Repository) GetTagNodes(ctx context.Context) ([]TagNode, error) {
+	nodes := []TagNode{}
+	if err := r.db.SelectContext(ctx, &nodes, "SELECT id, name, parent_id FROM tags WHERE status = ? AND hidden = FALSE ORDER BY name", TagStatusApproved); err != nil {
+		return nil, fmt.Errorf("select tag nodes: %w", err)
+	}
+
+	return nodes, nil
+}
+
+// SetTagParent はタグの親を設定する。parentID が nil の場合は最上位のタグにする。
+func (r *Repository) SetTagParent(ctx context.Context, tagID uuid.UUID, parentID *uuid.UUID) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM tags WHERE id = ? FOR UPDATE", tagID); err != nil {
+		return fmt.Errorf("select tag: %w", err)
+	}
+	if count == 0 {
+		return ErrTagNotFound
+	}
+
+	if parentID != nil {
+		if *parentID == tagID {
+			return ErrTagCycle
+		}
+		if err := tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM tags WHERE id = ? FOR UPDATE", *parentID); err != nil {
+			return fmt.Errorf("select parent tag: %w", err)
+		}
+		if count == 0 {
+			return ErrTagNotFound
+		}
+		isDescendant, err := isTagDescendant(ctx, tx, *parentID, tagID)
+		if err != nil {
+			return err
+		}
+		if isDescendant {
+			return ErrTagCycle
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tags SET parent_id = ? WHERE id = ?", parentID, tagID); err != nil {
+		return fmt.Errorf("update tag parent: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// isTagDescendant は tagID が ancestorID の子孫であるかを返す
+func isTagDescendant(ctx context.Context, tx *sqlx.Tx, tagID uuid.UUID, ancestorID uuid.UUID) (bool, error) {
+	var count int
+	if err := tx.GetContext(ctx, &count, `
+		WITH RECURSIVE `+tagAncestorsCTE+`
+		SELECT COUNT(*) FROM tag_ancestors WHERE tag_id = ? AND ancestor_id = ?`,
+		tagID, ancestorID); err != nil {
+		return false, fmt.Errorf("select tag ancestors: %w", err)
+	}
+
+	return count > 0, nil
+}
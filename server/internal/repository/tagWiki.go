@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	TagWikiRevision struct {
+		TagID                 uuid.UUID  `db:"tag_id"`
+		Revision              int        `db:"revision"`
+		Body                  string     `db:"body"`
+		RepresentativeStampID *uuid.UUID `db:"representative_stamp_id"`
+		// 代表スタンプの名前と画像 (代表スタンプがなければ nil)
+		RepresentativeStampName   *string    `db:"representative_stamp_name"`
+		RepresentativeStampFileID *uuid.UUID `db:"representative_stamp_file_id"`
+		EditorID                  uuid.UUID  `db:"editor_id"`
+		Comment                   string     `db:"comment"`
+		CreatedAt                 time.Time  `db:"created_at"`
+	}
+
+	SaveTagWikiParams struct {
+		TagID                 uuid.UUID
+		Body                  string
+		RepresentativeStampID *uuid.UUID
+		EditorID              uuid.UUID
+		Comment               string
+		// BaseRevision は編集元の版。指定されていて最新の版と異なる場合は ErrTagWikiConflict を返す
+		BaseRevision *int
+	}
+)
+
+var ErrTagWikiConflict = errors.New("tag wiki has been edited since the base revision")
+
+// GetTagWikiRevisions はタグのwikiの版を新しい順に最大 limit 件返す
+func (r *Repository) GetTagWikiRevisions(ctx context.Context, tagID uuid.UUID, limit int) ([]*TagWikiRevision, error) {
+	revisions := []*TagWikiRevision{}
+	if err := r.db.SelectContext(ctx, &revisions, `
+		SELECT
+			w.tag_id, w.revision, w.body, w.representative_stamp_id,
+			s.name AS representative_stamp_name, s.file_id AS representative_stamp_file_id,
+			w.editor_id, w.comment, w.created_at
+		FROM tag_wiki_revisions w
+		LEFT JOIN stamps s ON s.id = w.representative_stamp_id
+		WHERE w.tag_id = ?
+		ORDER BY w.revision DESC
+		LIMIT ?`, tagID, limit); err != nil {
+		return nil, fmt.Errorf("select tag wiki revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// SaveTagWiki はタグのwikiの新しい版を保存し、その版番号を返す
+func (r *Repository) SaveTagWiki(ctx context.Context, params SaveTagWikiParams) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM tags WHERE id = ? FOR UPDATE", params.TagID); err != nil {
+		return 0, fmt.Errorf("select tag: %w", err)
+	}
+	if count == 0 {
+		return 0, ErrTagNotFound
+	}
+	if params.RepresentativeStampID != nil {
+		if err := tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM stamps WHERE id = ?", *params.RepresentativeStampID); err != nil {
+			return 0, fmt.Errorf("select stamp: %w", err)
+		}
+		if count == 0 {
+			return 0, ErrStampNotFound
+		}
+	}
+
+	var current int
+	if err := tx.GetContext(ctx, &current, "SELECT COALESCE(MAX(revision), 0) FROM tag_wiki_revisions WHERE tag_id = ?", params.TagID); err != nil {
+		return 0, fmt.Errorf("select current tag wiki revision: %w", err)
+	}
+	if params.BaseRevision != nil && *params.BaseRevision != current {
+		return 0, ErrTagWikiConflict
+	}
+
+	revision := current + 1
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO tag_wiki_revisions (tag_id, revision, body, representative_stamp_id, editor_id, comment, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		params.TagID, revision, params.Body, params.RepresentativeStampID, params.EditorID, params.Comment, time.Now()); err != nil {
+		return 0, fmt.Errorf("insert tag wiki revision: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return revision, nil
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StampWeeklyUsage struct {
+	StampID   uuid.UUID `db:"stamp_id"`
+	StampName string    `db:"stamp_name"`
+	FileID    uuid.UUID `db:"file_id"`
+	CreatedAt time.Time `db:"created_at"`
+	WeekIndex int       `db:"week_index"`
+	Count     int64     `db:"count"`
+}
+
+// GetWeeklyUsages は today より前の weeks 週分の使用回数を週ごとに集計して返す。
+// WeekIndex は直近7日間が 0 で、過去に遡るごとに 1 ずつ増える。
+func (r *Repository) GetWeeklyUsages(ctx context.Context, today time.Time, weeks int) ([]StampWeeklyUsage, error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	from := today.AddDate(0, 0, -7*weeks)
+
+	usages := []StampWeeklyUsage{}
+	if err := r.db.SelectContext(ctx, &usages, `
+		SELECT
+			u.stamp_id, s.name AS stamp_name, s.file_id, s.created_at,
+			FLOOR((DATEDIFF(?, u.date) - 1) / 7) AS week_index,
+			SUM(u.reaction_count + u.message_count) AS count
+		FROM stamp_daily_usages u
+		JOIN stamps s ON s.id = u.stamp_id
+		WHERE u.date >= ? AND u.date < ?
+		GROUP BY u.stamp_id, week_index`,
+		today, from, today); err != nil {
+		return nil, fmt.Errorf("select weekly usages: %w", err)
+	}
+
+	return usages, nil
+}
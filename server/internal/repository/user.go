@@ -27,12 +27,8 @@ type (
 func (r *Repository) GetUser(ctx context.Context, userID uuid.UUID) (*User, error) {
 	user := &User{}
 	user.ID = userID
-	user.IsAdmin = false // 仮でfalseに設定
-	// isAdmin, err := r.IsAdmin(ctx, userID)
-	// if err != nil {
-	// 	return nil, fmt.Errorf("check admin: %w", err)
-	// }
-	// user.IsAdmin = isAdmin.(bool)
+	// 管理者かどうかは ADMIN_USERS の設定をもとに handler で判定する
+	user.IsAdmin = false
 
 	if err := r.db.SelectContext(ctx, &user.StampsUserOwned, "SELECT id , name ,file_id FROM stamps WHERE creator_id = ?", userID); err != nil {
 		return nil, fmt.Errorf("select stamps by creatorID: %w", err)
@@ -49,7 +45,7 @@ func (r *Repository) GetUser(ctx context.Context, userID uuid.UUID) (*User, erro
 			WHERE st.creator_id = ?`, userID); err != nil {
 		return nil, fmt.Errorf("select stamp_tags by creatorID: %w", err)
 	}
-	if err := r.db.SelectContext(ctx, &user.DescriptionsUserCreated, `SELECT
+	if err := r.db.SelectContext(ctx, &user.DescriptionsUserCreated, `SELECT DISTINCT
 			s.id , s.name , s.file_id 
 			FROM stamp_descriptions AS d
 			JOIN stamps AS s ON d.stamp_id = s.id 
@@ -0,0 +1,110 @@
+// Package textanalysis は検索のために説明文を言語に応じて解析する。
+package textanalysis
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// EnglishWords は英語の文を小文字の単語に分け、それぞれを語幹にする
+func EnglishWords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	stems := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.Trim(w, "'"); w != "" {
+			stems = append(stems, EnglishStem(w))
+		}
+	}
+
+	return stems
+}
+
+// EnglishStem は英単語の語尾の変化 (複数形・過去形・進行形など) を大まかに取り除く。
+// 検索語と説明文の両方に同じ処理をかけるので、正しい語幹になることより同じ語が同じ形になることを優先する。
+// 複数形、過去形・進行形、語末の e の順に取り除くので、use / uses / used / using はどれも us になる
+func EnglishStem(word string) string {
+	word = strings.TrimSuffix(word, "'s")
+
+	// 複数形・三人称単数
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses") || strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes") || strings.HasSuffix(word, "xes"):
+		word = word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+
+	// 過去形・進行形。残りに母音がなければ (bring, red, need など) 語尾の変化ではないとみなす
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ied"):
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "eed"):
+		if hasVowel(word[:len(word)-3]) {
+			word = word[:len(word)-1]
+		}
+	case strings.HasSuffix(word, "ed") && hasVowel(word[:len(word)-2]):
+		word = undoubleConsonant(word[:len(word)-2])
+	case strings.HasSuffix(word, "ing") && hasVowel(word[:len(word)-3]):
+		word = undoubleConsonant(word[:len(word)-3])
+	}
+
+	// hope / hoped / hoping のように、語尾の変化で消える e を揃える
+	if len(word) > 2 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "ee") {
+		word = word[:len(word)-1]
+	}
+
+	return word
+}
+
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
+
+// undoubleConsonant は running から ing を取り除いた runn のように、語尾で重なった子音を1つにする
+func undoubleConsonant(stem string) string {
+	n := len(stem)
+	if n >= 2 && stem[n-1] == stem[n-2] && !strings.ContainsRune("aeiouls", rune(stem[n-1])) {
+		return stem[:n-1]
+	}
+
+	return stem
+}
+
+// EnglishOccurrences は英語の text で term (複数の単語でもよい) が単語の並びとして現れる回数を返す
+func EnglishOccurrences(term string, text string) int {
+	terms := EnglishWords(term)
+	if len(terms) == 0 || text == "" {
+		return 0
+	}
+	words := EnglishWords(text)
+	count := 0
+	for i := 0; i+len(terms) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(terms)], terms) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// EnglishStemPattern は DB で英語の説明文を絞り込むための、term の最初の単語の語幹で始まる単語に一致する正規表現を返す。
+// 語幹は元の単語の先頭部分になる (ies / ied から作った y だけは i になる) ので、EnglishOccurrences が数える単語にはすべて一致する。
+// 単語の途中には一致しないが、語幹で始まる別の単語には一致しうるので、正確な判定は EnglishOccurrences で行う。単語がなければ空文字列
+func EnglishStemPattern(term string) string {
+	words := EnglishWords(term)
+	if len(words) == 0 || words[0] == "" {
+		return ""
+	}
+	stem := words[0]
+	if strings.HasSuffix(stem, "y") {
+		return `\b` + regexp.QuoteMeta(strings.TrimSuffix(stem, "y")) + "[yi]"
+	}
+
+	return `\b` + regexp.QuoteMeta(stem)
+}
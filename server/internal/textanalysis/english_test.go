@@ -0,0 +1,115 @@
+package textanalysis
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestEnglishStemGroupsInflections(t *testing.T) {
+	groups := [][]string{
+		{"use", "uses", "used", "using"},
+		{"hope", "hopes", "hoped", "hoping"},
+		{"run", "runs", "running"},
+		{"stop", "stops", "stopped", "stopping"},
+		{"fall", "falls", "falling"},
+		{"party", "parties", "partied"},
+		{"study", "studies", "studied", "studying"},
+		{"watch", "watches", "watched", "watching"},
+		{"box", "boxes", "boxed"},
+		{"glass", "glasses"},
+		{"bus", "buses"},
+		{"create", "creates", "created", "creating"},
+		{"agree", "agrees", "agreed", "agreeing"},
+		{"need", "needs", "needed", "needing"},
+		{"bring", "brings", "bringing"},
+		{"see", "sees", "seeing"},
+		{"cat", "cats", "cat's"},
+	}
+	for _, group := range groups {
+		want := EnglishStem(group[0])
+		for _, word := range group[1:] {
+			if got := EnglishStem(word); got != want {
+				t.Errorf("EnglishStem(%q) = %q, want %q (same as %q)", word, got, want, group[0])
+			}
+		}
+	}
+}
+
+func TestEnglishStemKeepsShortAndUninflectedWords(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"red", "red"},
+		{"bed", "bed"},
+		{"this", "this"},
+		{"was", "was"},
+		{"thing", "thing"},
+		{"string", "string"},
+		{"status", "status"},
+		{"class", "class"},
+	}
+	for _, tt := range tests {
+		if got := EnglishStem(tt.word); got != tt.want {
+			t.Errorf("EnglishStem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestEnglishOccurrences(t *testing.T) {
+	tests := []struct {
+		term string
+		text string
+		want int
+	}{
+		{"use", "Used when someone uses it. Keep using it!", 3},
+		{"party", "Parties and a party", 2},
+		{"thumbs up", "Thumbs up! A thumb upward", 1},
+		{"run", "", 0},
+		{"", "run", 0},
+		{"cat", "concatenate", 0},
+	}
+	for _, tt := range tests {
+		if got := EnglishOccurrences(tt.term, tt.text); got != tt.want {
+			t.Errorf("EnglishOccurrences(%q, %q) = %d, want %d", tt.term, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEnglishStemPattern(t *testing.T) {
+	tests := []struct {
+		term    string
+		match   []string
+		noMatch []string
+	}{
+		{"using", []string{"use", "used", "it uses", "using it"}, []string{"because", "just", "music", "abuse"}},
+		{"flies", []string{"fly", "it flies", "flied"}, []string{"butterfly"}},
+		{"Watched", []string{"watch", "watches", "watching"}, []string{"stopwatch"}},
+		{"c++", []string{"c++", "cat"}, []string{"abc"}},
+	}
+	for _, tt := range tests {
+		pattern := EnglishStemPattern(tt.term)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			t.Fatalf("EnglishStemPattern(%q) = %q: %v", tt.term, pattern, err)
+		}
+		for _, text := range tt.match {
+			if !re.MatchString(text) {
+				t.Errorf("EnglishStemPattern(%q) = %q does not match %q", tt.term, pattern, text)
+			}
+		}
+		for _, text := range tt.noMatch {
+			if re.MatchString(text) {
+				t.Errorf("EnglishStemPattern(%q) = %q matches %q", tt.term, pattern, text)
+			}
+			// 絞り込みで落とす説明文には term が現れていてはいけない
+			if n := EnglishOccurrences(tt.term, text); n > 0 {
+				t.Errorf("EnglishOccurrences(%q, %q) = %d, but the pattern does not match", tt.term, text, n)
+			}
+		}
+	}
+
+	if got := EnglishStemPattern("!?"); got != "" {
+		t.Errorf("EnglishStemPattern(%q) = %q, want empty", "!?", got)
+	}
+}
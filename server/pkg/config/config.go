@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/go-sql-driver/mysql"
@@ -41,6 +42,11 @@ func MySQL() *mysql.Config {
 	return c
 }
 
+// PublicURL はフィードなどに載せるクライアントの公開URLを返す
+func PublicURL() string {
+	return strings.TrimRight(getEnv("PUBLIC_URL", "https://stampedia.trap.show"), "/")
+}
+
 // AllowedOrigins はCORSで許可されるオリジンのリストを返す
 // ALLOWED_ORIGINS環境変数でカンマ区切りで指定
 func AllowedOrigins() []string {
@@ -58,6 +64,131 @@ func AllowedOrigins() []string {
 	return origins
 }
 
+// AdminUsers は管理者の traQ ID の一覧を返す
+// ADMIN_USERS環境変数でカンマ区切りで指定
+func AdminUsers() []string {
+	raw := getEnv("ADMIN_USERS", "")
+	parts := strings.Split(raw, ",")
+	users := make([]string, 0, len(parts))
+	for _, p := range parts {
+		u := strings.ToLower(strings.TrimSpace(p))
+		if u == "" {
+			continue
+		}
+		users = append(users, u)
+	}
+
+	return users
+}
+
+// TagProposalMode は管理者以外が作成したタグを提案中として扱うかを返す
+// TAG_PROPOSAL_MODE環境変数が "true" のとき有効
+func TagProposalMode() bool {
+	return getEnv("TAG_PROPOSAL_MODE", "false") == "true"
+}
+
+// TagApprovalThreshold は提案中のタグが自動で承認されるのに必要な、そのタグを付けたユーザーの人数を返す
+// TAG_APPROVAL_THRESHOLD環境変数で指定 (デフォルトは3)
+func TagApprovalThreshold() int {
+	n, err := strconv.Atoi(getEnv("TAG_APPROVAL_THRESHOLD", "3"))
+	if err != nil || n < 1 {
+		return 3
+	}
+
+	return n
+}
+
+// DescriptionMaxLength は説明文の本文の最大文字数を返す
+// DESCRIPTION_MAX_LENGTH環境変数で指定 (デフォルトは2000)
+func DescriptionMaxLength() int {
+	n, err := strconv.Atoi(getEnv("DESCRIPTION_MAX_LENGTH", "2000"))
+	if err != nil || n < 1 {
+		return 2000
+	}
+
+	return n
+}
+
+// DescriptionMaxLinks は1つの説明文に含められるリンクの数を返す
+// DESCRIPTION_MAX_LINKS環境変数で指定 (デフォルトは3)
+func DescriptionMaxLinks() int {
+	n, err := strconv.Atoi(getEnv("DESCRIPTION_MAX_LINKS", "3"))
+	if err != nil || n < 0 {
+		return 3
+	}
+
+	return n
+}
+
+// NGWords は説明文やタグ名に含めてはいけない語の一覧を返す
+// NG_WORDS環境変数でカンマ区切り、NG_WORDS_FILE環境変数で1行に1語のファイルを指定 (両方指定すると合わせたもの)
+func NGWords() ([]string, error) {
+	words := []string{}
+	for _, w := range strings.Split(getEnv("NG_WORDS", ""), ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	path := getEnv("NG_WORDS_FILE", "")
+	if path == "" {
+		return words, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read NG_WORDS_FILE: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if w := strings.TrimSpace(line); w != "" && !strings.HasPrefix(w, "#") {
+			words = append(words, w)
+		}
+	}
+
+	return words, nil
+}
+
+// SubmissionRateLimit は1人のユーザーが1分間に投稿できる説明文・タグそれぞれの数を返す
+// SUBMISSION_RATE_LIMIT環境変数で指定 (デフォルトは10、0で制限なし)
+func SubmissionRateLimit() int {
+	n, err := strconv.Atoi(getEnv("SUBMISSION_RATE_LIMIT", "10"))
+	if err != nil || n < 0 {
+		return 10
+	}
+
+	return n
+}
+
+// DescriptionGenerator は説明文の生成に使う生成器の種類を返す
+// DESCRIPTION_GENERATOR環境変数で "openai" (OpenAI 互換の API) か "fake" (開発用) を指定。未設定なら生成しない
+func DescriptionGenerator() string {
+	return getEnv("DESCRIPTION_GENERATOR", "")
+}
+
+// DescriptionGeneratorBaseURL は OpenAI 互換の API のベースURLを返す
+func DescriptionGeneratorBaseURL() string {
+	return getEnv("DESCRIPTION_GENERATOR_BASE_URL", "https://api.openai.com/v1")
+}
+
+// DescriptionGeneratorAPIKey は OpenAI 互換の API のキーを返す
+func DescriptionGeneratorAPIKey() string {
+	return getEnv("DESCRIPTION_GENERATOR_API_KEY", "")
+}
+
+// DescriptionGeneratorModel は説明文の生成に使うモデルの名前を返す
+func DescriptionGeneratorModel() string {
+	return getEnv("DESCRIPTION_GENERATOR_MODEL", "gpt-4o-mini")
+}
+
+// DescriptionGenerationBatchSize は1回の実行で説明文を生成するスタンプの最大数を返す
+// DESCRIPTION_GENERATION_BATCH_SIZE環境変数で指定 (デフォルトは50)
+func DescriptionGenerationBatchSize() int {
+	n, err := strconv.Atoi(getEnv("DESCRIPTION_GENERATION_BATCH_SIZE", "50"))
+	if err != nil || n < 1 {
+		return 50
+	}
+
+	return n
+}
+
 // 環境変数APP_ENVを確認して、開発モードで実行されているかを IsDevelopment に
 func IsDevelopment() bool {
 	// APP_ENV変数で明示的に環境を判定。デフォルトは "development"
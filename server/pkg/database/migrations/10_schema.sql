@@ -0,0 +1,21 @@
+-- +goose Up
+-- 複数のスタンプへのタグの一括付与・削除。まとめて取り消せるように実際に変更したスタンプを記録する
+CREATE TABLE IF NOT EXISTS `tag_batch_operations` (
+	`id` CHAR(36) NOT NULL,
+	`tag_id` CHAR(36) NOT NULL,
+	`action` VARCHAR(8) NOT NULL,
+	`creator_id` CHAR(36) NOT NULL,
+	`created_at` DATETIME NOT NULL,
+	`undone_at` DATETIME NULL,
+	PRIMARY KEY (`id`),
+	FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE
+);
+-- 削除したタグ付けを取り消しで元に戻せるよう、元の creator_id と created_at を保持する
+CREATE TABLE IF NOT EXISTS `tag_batch_operation_items` (
+	`operation_id` CHAR(36) NOT NULL,
+	`stamp_id` CHAR(36) NOT NULL,
+	`link_creator_id` CHAR(36) NOT NULL,
+	`link_created_at` DATETIME NOT NULL,
+	PRIMARY KEY (`operation_id`, `stamp_id`),
+	FOREIGN KEY (`operation_id`) REFERENCES `tag_batch_operations`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+-- +goose Up
+-- タグのwiki (Markdownの本文と代表スタンプ) の編集履歴。最新の版が現在の内容
+CREATE TABLE IF NOT EXISTS `tag_wiki_revisions` (
+	`tag_id` CHAR(36) NOT NULL,
+	`revision` INT NOT NULL,
+	`body` TEXT NOT NULL,
+	`representative_stamp_id` CHAR(36) NULL,
+	`editor_id` CHAR(36) NOT NULL,
+	`comment` VARCHAR(100) NOT NULL DEFAULT '',
+	`created_at` DATETIME NOT NULL,
+	PRIMARY KEY (`tag_id`, `revision`),
+	FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE,
+	FOREIGN KEY (`representative_stamp_id`) REFERENCES `stamps`(`id`)
+);
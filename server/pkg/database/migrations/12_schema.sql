@@ -0,0 +1,16 @@
+-- +goose Up
+-- 提案中 (proposed) のタグは承認 (approved) されるまでタグ一覧に表示しない。既存のタグは承認済みとする
+ALTER TABLE `tags` ADD COLUMN `status` VARCHAR(16) NOT NULL DEFAULT 'approved';
+-- ユーザーへのお知らせ (タグの提案の承認・却下など)
+CREATE TABLE IF NOT EXISTS `notifications` (
+	`id` CHAR(36) NOT NULL,
+	`user_id` CHAR(36) NOT NULL,
+	`type` VARCHAR(32) NOT NULL,
+	`subject_id` CHAR(36) NULL,
+	`subject_name` VARCHAR(64) NOT NULL DEFAULT '',
+	`message` TEXT NOT NULL,
+	`created_at` DATETIME NOT NULL,
+	`read_at` DATETIME NULL,
+	PRIMARY KEY (`id`),
+	KEY (`user_id`, `created_at`)
+);
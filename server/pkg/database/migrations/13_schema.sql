@@ -0,0 +1,16 @@
+-- +goose Up
+-- 説明文の作成・編集・削除・巻き戻しの履歴。description は操作後の本文 (削除の場合は NULL)
+CREATE TABLE IF NOT EXISTS `stamp_description_revisions` (
+	`stamp_id` CHAR(36) NOT NULL,
+	`creator_id` CHAR(36) NOT NULL,
+	`revision` INT NOT NULL,
+	`action` VARCHAR(8) NOT NULL,
+	`description` TEXT NULL,
+	`editor_id` CHAR(36) NOT NULL,
+	`created_at` DATETIME NOT NULL,
+	PRIMARY KEY (`stamp_id`, `creator_id`, `revision`),
+	FOREIGN KEY (`stamp_id`) REFERENCES `stamps`(`id`)
+);
+-- 既存の説明文を最初の版として記録する
+INSERT INTO `stamp_description_revisions` (`stamp_id`, `creator_id`, `revision`, `action`, `description`, `editor_id`, `created_at`)
+SELECT `stamp_id`, `creator_id`, 1, 'create', `description`, `creator_id`, `updated_at` FROM `stamp_descriptions`;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- 説明文への投票 (value は 1: 役に立つ, -1: 役に立たない)。1人1つの説明文につき1票
+CREATE TABLE IF NOT EXISTS `description_votes` (
+	`stamp_id` CHAR(36) NOT NULL,
+	`creator_id` CHAR(36) NOT NULL,
+	`voter_id` CHAR(36) NOT NULL,
+	`value` TINYINT NOT NULL,
+	`created_at` DATETIME NOT NULL,
+	`updated_at` DATETIME NOT NULL,
+	PRIMARY KEY (`stamp_id`, `creator_id`, `voter_id`),
+	FOREIGN KEY (`stamp_id`, `creator_id`) REFERENCES `stamp_descriptions`(`stamp_id`, `creator_id`) ON DELETE CASCADE
+);
@@ -0,0 +1,10 @@
+-- +goose Up
+-- 説明文中のスタンプ参照 (:stamp_name:)。(stamp_id, creator_id) の説明文が referenced_stamp_id のスタンプに言及している
+CREATE TABLE IF NOT EXISTS `description_stamp_references` (
+	`stamp_id` CHAR(36) NOT NULL,
+	`creator_id` CHAR(36) NOT NULL,
+	`referenced_stamp_id` CHAR(36) NOT NULL,
+	PRIMARY KEY (`stamp_id`, `creator_id`, `referenced_stamp_id`),
+	FOREIGN KEY (`stamp_id`, `creator_id`) REFERENCES `stamp_descriptions`(`stamp_id`, `creator_id`) ON DELETE CASCADE,
+	FOREIGN KEY (`referenced_stamp_id`) REFERENCES `stamps`(`id`) ON DELETE CASCADE
+);
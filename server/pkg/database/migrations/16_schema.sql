@@ -0,0 +1,12 @@
+-- +goose Up
+-- 説明文の構造化された項目 (意味, 使い方, 由来, 例文)。いずれも任意で、例文は1行に1つ
+ALTER TABLE `stamp_descriptions`
+	ADD COLUMN `meaning` TEXT NULL,
+	ADD COLUMN `usage_notes` TEXT NULL,
+	ADD COLUMN `origin` TEXT NULL,
+	ADD COLUMN `examples` TEXT NULL;
+ALTER TABLE `stamp_description_revisions`
+	ADD COLUMN `meaning` TEXT NULL,
+	ADD COLUMN `usage_notes` TEXT NULL,
+	ADD COLUMN `origin` TEXT NULL,
+	ADD COLUMN `examples` TEXT NULL;
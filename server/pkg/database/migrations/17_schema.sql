@@ -0,0 +1,16 @@
+-- +goose Up
+-- スタンプの使用例として登録された traQ のメッセージ。登録時点の本文と投稿者を保存する
+CREATE TABLE IF NOT EXISTS `stamp_message_examples` (
+	`stamp_id` CHAR(36) NOT NULL,
+	`message_id` CHAR(36) NOT NULL,
+	`channel_id` CHAR(36) NOT NULL,
+	`channel_path` VARCHAR(255) NOT NULL,
+	`author_id` CHAR(36) NOT NULL,
+	`author_name` VARCHAR(32) NOT NULL,
+	`content` TEXT NOT NULL,
+	`posted_at` DATETIME NOT NULL,
+	`creator_id` CHAR(36) NOT NULL,
+	`created_at` DATETIME NOT NULL,
+	PRIMARY KEY (`stamp_id`, `message_id`),
+	FOREIGN KEY (`stamp_id`) REFERENCES `stamps`(`id`)
+);
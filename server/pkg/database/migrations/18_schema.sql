@@ -0,0 +1,23 @@
+-- +goose Up
+-- 通報によって非表示にされた内容 (削除はせず一覧や検索から除外する)
+ALTER TABLE `stamp_descriptions` ADD COLUMN `hidden` BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE `tags` ADD COLUMN `hidden` BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE `stamp_tags` ADD COLUMN `hidden` BOOLEAN NOT NULL DEFAULT FALSE;
+-- 説明文 (stamp_id, creator_id)・タグ (tag_id)・タグ付け (stamp_id, tag_id) への通報。
+-- 対象が削除されても通報の記録は残すため外部キーは張らない
+CREATE TABLE IF NOT EXISTS `reports` (
+	`id` CHAR(36) NOT NULL,
+	`target_type` VARCHAR(16) NOT NULL,
+	`stamp_id` CHAR(36) NULL,
+	`creator_id` CHAR(36) NULL,
+	`tag_id` CHAR(36) NULL,
+	`reason` TEXT NOT NULL,
+	`reporter_id` CHAR(36) NOT NULL,
+	`status` VARCHAR(16) NOT NULL DEFAULT 'open',
+	`resolution` VARCHAR(16) NULL,
+	`resolver_id` CHAR(36) NULL,
+	`resolved_at` DATETIME NULL,
+	`created_at` DATETIME NOT NULL,
+	PRIMARY KEY (`id`),
+	KEY (`status`, `created_at`)
+);
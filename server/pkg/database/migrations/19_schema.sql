@@ -0,0 +1,10 @@
+-- +goose Up
+-- 説明文の出所。human はユーザーが書いたもの、machine は自動で生成したもの。
+-- 生成した説明文にはモデル名・確信度と、生成に使ったスタンプ画像の file_id を記録し、画像が変わったら作り直す
+ALTER TABLE `stamp_descriptions`
+	ADD COLUMN `source` VARCHAR(16) NOT NULL DEFAULT 'human',
+	ADD COLUMN `model` VARCHAR(255) NULL,
+	ADD COLUMN `confidence` DOUBLE NULL,
+	ADD COLUMN `source_file_id` CHAR(36) NULL;
+-- 以前にオフラインの LLM の出力を一括で取り込んだ説明文は生成したものとして扱う
+UPDATE `stamp_descriptions` SET `source` = 'machine' WHERE `creator_id` = '3b261ff3-f940-4e2c-a626-27387b6dd71b';
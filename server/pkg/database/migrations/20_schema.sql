@@ -0,0 +1,26 @@
+-- +goose Up
+-- 説明文の言語 (ISO 639-1 の言語コード)。1人のユーザーは1つのスタンプに言語ごとに1つずつ説明文を書ける。
+-- 既存の説明文はすべて日本語として扱う
+ALTER TABLE `description_votes` DROP FOREIGN KEY `description_votes_ibfk_1`;
+ALTER TABLE `description_stamp_references` DROP FOREIGN KEY `description_stamp_references_ibfk_1`;
+ALTER TABLE `stamp_descriptions`
+	ADD COLUMN `lang` VARCHAR(8) NOT NULL DEFAULT 'ja' AFTER `creator_id`,
+	DROP PRIMARY KEY,
+	ADD PRIMARY KEY (`stamp_id`, `creator_id`, `lang`);
+ALTER TABLE `stamp_description_revisions`
+	ADD COLUMN `lang` VARCHAR(8) NOT NULL DEFAULT 'ja' AFTER `creator_id`,
+	DROP PRIMARY KEY,
+	ADD PRIMARY KEY (`stamp_id`, `creator_id`, `lang`, `revision`);
+ALTER TABLE `description_votes`
+	ADD COLUMN `lang` VARCHAR(8) NOT NULL DEFAULT 'ja' AFTER `creator_id`,
+	DROP PRIMARY KEY,
+	ADD PRIMARY KEY (`stamp_id`, `creator_id`, `lang`, `voter_id`),
+	ADD FOREIGN KEY (`stamp_id`, `creator_id`, `lang`) REFERENCES `stamp_descriptions`(`stamp_id`, `creator_id`, `lang`) ON DELETE CASCADE;
+ALTER TABLE `description_stamp_references`
+	ADD COLUMN `lang` VARCHAR(8) NOT NULL DEFAULT 'ja' AFTER `creator_id`,
+	DROP PRIMARY KEY,
+	ADD PRIMARY KEY (`stamp_id`, `creator_id`, `lang`, `referenced_stamp_id`),
+	ADD FOREIGN KEY (`stamp_id`, `creator_id`, `lang`) REFERENCES `stamp_descriptions`(`stamp_id`, `creator_id`, `lang`) ON DELETE CASCADE;
+-- 説明文への通報は言語も含めて対象を表す (説明文以外への通報では NULL)
+ALTER TABLE `reports` ADD COLUMN `lang` VARCHAR(8) NULL AFTER `creator_id`;
+UPDATE `reports` SET `lang` = 'ja' WHERE `target_type` = 'description';
@@ -0,0 +1,8 @@
+-- +goose Up
+-- 説明文の版にも出所を記録し、生成した説明文の版に巻き戻したときに生成したものとして復元できるようにする
+ALTER TABLE `stamp_description_revisions`
+	ADD COLUMN `source` VARCHAR(16) NOT NULL DEFAULT 'human',
+	ADD COLUMN `model` VARCHAR(255) NULL,
+	ADD COLUMN `confidence` DOUBLE NULL,
+	ADD COLUMN `source_file_id` CHAR(36) NULL;
+UPDATE `stamp_description_revisions` SET `source` = 'machine' WHERE `creator_id` = '3b261ff3-f940-4e2c-a626-27387b6dd71b';
@@ -0,0 +1,11 @@
+-- +goose Up
+-- 説明文の生成に失敗したスタンプ。失敗が続くスタンプは間隔を空けて生成し直し、ほかのスタンプの生成を妨げないようにする
+CREATE TABLE IF NOT EXISTS `description_generation_failures` (
+	`stamp_id` CHAR(36) NOT NULL,
+	`file_id` CHAR(36) NOT NULL,
+	`failures` INT NOT NULL,
+	`last_error` TEXT NOT NULL,
+	`failed_at` DATETIME NOT NULL,
+	PRIMARY KEY (`stamp_id`),
+	FOREIGN KEY (`stamp_id`) REFERENCES `stamps`(`id`) ON DELETE CASCADE
+);
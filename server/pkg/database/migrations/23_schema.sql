@@ -0,0 +1,8 @@
+-- +goose Up
+-- 5_schema で created_at を追加する前からあるタグ付けには、追加した時点の日時が入ってしまっている。
+-- いつ付けたかわからないので NULL にし、期間ごとの集計に含めないようにする
+ALTER TABLE `stamp_tags` MODIFY COLUMN `created_at` DATETIME NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE `tag_batch_operation_items` MODIFY COLUMN `link_created_at` DATETIME NULL;
+SET @stamp_tags_created_at_added = (SELECT MIN(`tstamp`) FROM `goose_db_version` WHERE `version_id` = 5 AND `is_applied` = TRUE);
+UPDATE `stamp_tags` SET `created_at` = NULL WHERE `created_at` <= @stamp_tags_created_at_added;
+UPDATE `tag_batch_operation_items` SET `link_created_at` = NULL WHERE `link_created_at` <= @stamp_tags_created_at_added;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- 期間別ランキングのスナップショット (stamp_daily_usages から同期のたびに再計算する)
+CREATE TABLE IF NOT EXISTS `stamp_ranking_snapshots` (
+	`period` VARCHAR(8) NOT NULL,
+	`stamp_id` CHAR(36) NOT NULL,
+	`current_rank` INT UNSIGNED NOT NULL,
+	`count` BIGINT UNSIGNED NOT NULL,
+	`previous_rank` INT UNSIGNED NULL,
+	`previous_count` BIGINT UNSIGNED NOT NULL DEFAULT 0,
+	`computed_at` DATETIME NOT NULL,
+	PRIMARY KEY (`period`, `stamp_id`),
+	KEY (`period`, `current_rank`),
+	FOREIGN KEY (`stamp_id`) REFERENCES `stamps`(`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- +goose Up
+-- 期間ごとの貢献を集計できるよう、stamp_tags にタグ付け日時を追加
+ALTER TABLE `stamp_tags` ADD COLUMN `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- フィードリーダー用のユーザーごとの秘密トークン (SHA-256 ハッシュのみ保存する)
+CREATE TABLE IF NOT EXISTS `feed_tokens` (
+	`user_id` CHAR(36) NOT NULL,
+	`token_hash` CHAR(64) NOT NULL,
+	`created_at` DATETIME NOT NULL,
+	PRIMARY KEY (`user_id`),
+	UNIQUE KEY (`token_hash`)
+);
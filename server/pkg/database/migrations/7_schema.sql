@@ -0,0 +1,13 @@
+-- +goose Up
+-- 統合されたタグの旧名。旧名での検索・参照を統合先のタグに解決するために使う
+CREATE TABLE IF NOT EXISTS `tag_aliases` (
+	`name` VARCHAR(32) NOT NULL,
+	`tag_id` CHAR(36) NOT NULL,
+	`source_tag_id` CHAR(36) NOT NULL,
+	`creator_id` CHAR(36) NOT NULL,
+	`created_at` DATETIME NOT NULL,
+	PRIMARY KEY (`name`),
+	UNIQUE KEY (`source_tag_id`),
+	KEY (`tag_id`),
+	FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE
+);
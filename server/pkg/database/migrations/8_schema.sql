@@ -0,0 +1,4 @@
+-- +goose Up
+-- タグの親子関係 (親タグを削除した場合、子タグは最上位になる)
+ALTER TABLE `tags` ADD COLUMN `parent_id` CHAR(36) NULL;
+ALTER TABLE `tags` ADD FOREIGN KEY (`parent_id`) REFERENCES `tags`(`id`) ON DELETE SET NULL;
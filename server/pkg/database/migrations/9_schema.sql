@@ -0,0 +1,14 @@
+-- +goose Up
+-- 管理者が管理するタグのカテゴリ (感情、色、キャラクターなど)
+CREATE TABLE IF NOT EXISTS `tag_categories` (
+	`id` CHAR(36) NOT NULL,
+	`name` VARCHAR(32) NOT NULL,
+	`color` CHAR(7) NOT NULL,
+	`display_order` INT NOT NULL DEFAULT 0,
+	`created_at` DATETIME NOT NULL,
+	`updated_at` DATETIME NOT NULL,
+	PRIMARY KEY (`id`),
+	UNIQUE KEY (`name`)
+);
+ALTER TABLE `tags` ADD COLUMN `category_id` CHAR(36) NULL;
+ALTER TABLE `tags` ADD FOREIGN KEY (`category_id`) REFERENCES `tag_categories`(`id`) ON DELETE SET NULL;
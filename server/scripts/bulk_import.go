@@ -19,9 +19,18 @@ type ResponseFromLlm struct {
 type TagInfo struct {
 	Name string `json:"name" db:"name"`
 }
-type Tag struct {
-	ID   uuid.UUID `json:"tag_id"`
-	Name string    `json:"tag_name"`
+type BulkItemResult struct {
+	Index   int        `json:"index"`
+	Target  string     `json:"target"`
+	StampID *uuid.UUID `json:"stamp_id"`
+	TagID   *uuid.UUID `json:"tag_id"`
+	Status  string     `json:"status"`
+	Reason  string     `json:"reason"`
+}
+type BulkResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Summary map[string]int   `json:"summary"`
+	Results []BulkItemResult `json:"results"`
 }
 type StampMetaAddition struct {
 	ID          uuid.UUID   `json:"stamp_id"`
@@ -29,10 +38,51 @@ type StampMetaAddition struct {
 	Description string      `json:"description"`
 }
 
+// postBulk は一括登録の API に body を送り、結果を返す。
+// 何度実行しても同じ結果になるよう、既にあるものは飛ばすか上書きする (ON_CONFLICT, 既定は skip)
+func postBulk(client *http.Client, url string, bearerToken string, body []byte) BulkResult {
+	query := "?on_conflict=" + os.Getenv("ON_CONFLICT")
+	if os.Getenv("DRY_RUN") == "true" {
+		query += "&dry_run=true"
+	}
+	req, err := http.NewRequest("POST", url+query, bytes.NewReader(body))
+	if err != nil {
+		log.Fatalf("failed to create request to %s: %v", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+bearerToken)
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatalf("failed to send request to %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatalf("failed to read body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+		log.Fatalf("unexpected status code from %s: %d %s", url, resp.StatusCode, bodyBytes)
+	}
+	var result BulkResult
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		log.Fatalf("failed to decode result: %v", err)
+	}
+	for _, r := range result.Results {
+		if r.Status == "error" || r.Status == "skipped" {
+			log.Printf("%s: item %d (%s): %s", r.Status, r.Index, r.Target, r.Reason)
+		}
+	}
+	log.Printf("%s: %v (dry_run=%t)", url, result.Summary, result.DryRun)
+	if resp.StatusCode == http.StatusConflict {
+		log.Fatalf("nothing was imported because some items failed")
+	}
+
+	return result
+}
+
 func main() {
 	bulk_tags_url := "https://1m25-11.trap.show/api/v1/bulk/tags"
 	bulk_stamps_meta_url := "https://1m25-11.trap.show/api/v1/bulk/stamps-meta"
-	tags_url := "https://1m25-11.trap.show/api/v1/tags"
 
 	bearerToken := os.Getenv("BEARER_TOKEN")
 	if bearerToken == "" {
@@ -70,44 +120,17 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to marshal tagInfos: %v", err)
 	}
-	req, err := http.NewRequest("POST", bulk_tags_url, bytes.NewReader(tagBody))
-	if err != nil {
-		log.Fatalf("failed to create bulk tags request: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+bearerToken)
 	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatalf("failed to send bulk tags request: %v", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusCreated {
-		log.Fatalf("unexpected status code from bulk tags request: %d", resp.StatusCode)
-	}
-
-	var tags []Tag
-	req, err = http.NewRequest("GET", tags_url, nil)
-	if err != nil {
-		log.Fatalf("failed to create get tags request: %v", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+bearerToken)
-	resp, err = client.Do(req)
-	if err != nil {
-		log.Fatalf("failed to get tags: %v", err)
-	}
-	defer resp.Body.Close()
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Fatalf("failed to read body: %v", err)
-	}
-	if err := json.Unmarshal(bodyBytes, &tags); err != nil {
-		log.Fatalf("failed to decode tags: %v", err)
-	}
+	tagResult := postBulk(client, bulk_tags_url, bearerToken, tagBody)
 
+	// サーバーで正規化されたタグ名ではなく、送ったタグ名から ID を引けるようにする
 	tagNameToID := make(map[string]uuid.UUID)
-	for _, tag := range tags {
-		tagNameToID[tag.Name] = tag.ID
+	for _, r := range tagResult.Results {
+		// dry run では作られるはずのタグはまだないので、既にあるタグだけを使う
+		if r.TagID == nil || (tagResult.DryRun && r.Status == "created") {
+			continue
+		}
+		tagNameToID[tagInfos[r.Index].Name] = *r.TagID
 	}
 
 	stampMetaAdditions := []StampMetaAddition{}
@@ -132,18 +155,5 @@ func main() {
 		log.Fatalf("failed to marshal stampMetaAdditions: %v", err)
 	}
 
-	req, err = http.NewRequest("POST", bulk_stamps_meta_url, bytes.NewReader(stampMetaBody))
-	if err != nil {
-		log.Fatalf("failed to create bulk stamps meta request: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+bearerToken)
-	resp, err = client.Do(req)
-	if err != nil {
-		log.Fatalf("failed to send bulk stamps meta request: %v", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent {
-		log.Fatalf("unexpected status code from bulk stamps meta request: %d", resp.StatusCode)
-	}
+	postBulk(client, bulk_stamps_meta_url, bearerToken, stampMetaBody)
 }